@@ -2,12 +2,20 @@ package core
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"net/http"
 	"net/http/httputil"
 )
 
 type Response struct {
 	BodyBytes []byte
+	// CacheHit is true when the response was served from the response
+	// cache of the client, without contacting the server.
+	CacheHit bool
+	// Revalidated is true when the server confirmed with 304 Not Modified
+	// that the response stored in the cache of the client is still valid.
+	// BodyBytes holds the stored body.
+	Revalidated bool
 	*http.Response
 }
 
@@ -27,6 +35,11 @@ func (r *Response) UnmarshalJson(target interface{}) error {
 	return json.Unmarshal(r.Bytes(), target)
 }
 
+// UnmarshalXml set the *target* parameter with the corresponding XML response.
+func (r *Response) UnmarshalXml(target interface{}) error {
+	return xml.Unmarshal(r.Bytes(), target)
+}
+
 // Debug let any request/response to be dumped, showing how the request/response
 // went through the wire.
 func (r *Response) Debug() string {
@@ -39,3 +39,17 @@ func TestResponse(t *testing.T) {
 	assert.Nil(t, err)
 	assert.EqualValues(t, "Hello World", response.Message)
 }
+
+func TestResponseUnmarshalXml(t *testing.T) {
+	resp := &Response{BodyBytes: []byte(`<response><message>Hello World</message></response>`)}
+
+	type TestResponse struct {
+		Message string `xml:"message"`
+	}
+
+	var response TestResponse
+	err := resp.UnmarshalXml(&response)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "Hello World", response.Message)
+}
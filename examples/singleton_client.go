@@ -16,11 +16,14 @@ func getHttpClient() gohttp.Client {
 	headers := make(http.Header)
 	headers.Set(gomime.HeaderContentType, gomime.ContentTypeJson)
 
-	client := gohttp.NewBuilder().
+	client, err := gohttp.NewBuilder().
 		SetHeaders(headers).
 		SetConnectionTimeout(2 * time.Second).
 		SetResponseTimeout(3 * time.Second).
 		SetUserAgent("Example").
 		Build()
+	if err != nil {
+		panic(err)
+	}
 	return client
 }
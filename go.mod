@@ -5,7 +5,7 @@ go 1.17
 require (
 	github.com/ajg/form v1.5.1
 	github.com/stretchr/testify v1.7.0
-	golang.org/x/time v0.0.0-20211116232009-f0f3c7e86c11
+	golang.org/x/time v0.3.0
 )
 
 require (
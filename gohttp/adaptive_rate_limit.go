@@ -0,0 +1,219 @@
+package gohttp
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	headerRetryAfter          = "Retry-After"
+	headerRateLimit           = "RateLimit"
+	headerRateLimitRemaining  = "RateLimit-Remaining"
+	headerRateLimitReset      = "RateLimit-Reset"
+	headerXRateLimitRemaining = "X-RateLimit-Remaining"
+	headerXRateLimitReset     = "X-RateLimit-Reset"
+
+	defaultAdaptiveRateLimitThreshold = 10
+
+	// minUnixReset is the smallest X-RateLimit-Reset value taken as a Unix
+	// time, as sent by GitHub, rather than as a number of seconds.
+	minUnixReset = 1000000000
+
+	maxDuration = time.Duration(math.MaxInt64)
+)
+
+// serverRateLimit is the rate limit last reported by a host.
+type serverRateLimit struct {
+	remaining    int
+	reset        time.Time
+	blockedUntil time.Time
+	next         time.Time
+}
+
+// adaptiveRateLimiter delays the requests to each host according to the
+// rate limit reported by its responses: the requests are spread until the
+// reset once the remaining ones drop to the threshold, paused until the
+// reset once none remain, and paused for the Retry-After of 429 responses.
+type adaptiveRateLimiter struct {
+	threshold int
+	now       func() time.Time
+
+	mutex sync.Mutex
+	hosts map[string]*serverRateLimit
+}
+
+func newAdaptiveRateLimiter(threshold int) *adaptiveRateLimiter {
+	return &adaptiveRateLimiter{
+		threshold: threshold,
+		now:       time.Now,
+		hosts:     make(map[string]*serverRateLimit),
+	}
+}
+
+// wait blocks until a request can be sent to the host, returning early with
+// an error if the context is done.
+func (l *adaptiveRateLimiter) wait(ctx context.Context, host string) error {
+	delay, _ := l.reserve(strings.ToLower(host), maxDuration)
+	return sleep(ctx, delay)
+}
+
+// allow checks whether a request can be sent to the host right away,
+// counting it in the remaining requests if so.
+func (l *adaptiveRateLimiter) allow(host string) bool {
+	_, ok := l.reserve(strings.ToLower(host), 0)
+	return ok
+}
+
+// reserve returns the delay before the next request to the host, counting
+// it in the remaining requests unless the delay is longer than maxDelay.
+func (l *adaptiveRateLimiter) reserve(host string, maxDelay time.Duration) (time.Duration, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	state, ok := l.hosts[host]
+	if !ok {
+		return 0, true
+	}
+	now := l.now()
+	if !now.Before(state.reset) && !now.Before(state.blockedUntil) {
+		delete(l.hosts, host)
+		return 0, true
+	}
+
+	at := now
+	remaining, next := state.remaining, state.next
+	if state.blockedUntil.After(at) {
+		at = state.blockedUntil
+	}
+	if state.reset.After(at) {
+		switch {
+		case remaining <= 0:
+			at = state.reset
+		case remaining <= l.threshold:
+			if next.After(at) {
+				at = next
+			}
+			next = at.Add(state.reset.Sub(at) / time.Duration(remaining))
+			remaining--
+		default:
+			remaining--
+		}
+	}
+
+	delay := at.Sub(now)
+	if delay > maxDelay {
+		return delay, false
+	}
+	state.remaining, state.next = remaining, next
+	return delay, true
+}
+
+// update records the rate limit reported by a response of the host.
+func (l *adaptiveRateLimiter) update(host string, statusCode int, header http.Header) {
+	now := l.now()
+	remaining, reset, hasLimit := parseRateLimit(header, now)
+	var retryAfter time.Time
+	hasRetryAfter := false
+	if statusCode == http.StatusTooManyRequests {
+		retryAfter, hasRetryAfter = parseRetryAfter(header, now)
+	}
+	if !hasLimit && !hasRetryAfter {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	host = strings.ToLower(host)
+	state, ok := l.hosts[host]
+	if !ok {
+		state = &serverRateLimit{}
+		l.hosts[host] = state
+	}
+	if hasLimit {
+		state.remaining = remaining
+		state.reset = reset
+	}
+	if hasRetryAfter && retryAfter.After(state.blockedUntil) {
+		state.blockedUntil = retryAfter
+	}
+}
+
+// parseRateLimit returns the remaining requests and the reset time given by
+// the IETF RateLimit headers, either the RateLimit field with its "r" and
+// "t" parameters or the RateLimit-Remaining and RateLimit-Reset fields, or
+// by the X-RateLimit-Remaining and X-RateLimit-Reset headers.
+func parseRateLimit(header http.Header, now time.Time) (int, time.Time, bool) {
+	if value := header.Get(headerRateLimit); value != "" {
+		params := parseRateLimitParams(value)
+		if remaining, reset, ok := parseRemainingAndReset(params["r"], params["t"], now, false); ok {
+			return remaining, reset, true
+		}
+		if remaining, reset, ok := parseRemainingAndReset(params["remaining"], params["reset"], now, false); ok {
+			return remaining, reset, true
+		}
+	}
+	if remaining, reset, ok := parseRemainingAndReset(header.Get(headerRateLimitRemaining), header.Get(headerRateLimitReset), now, false); ok {
+		return remaining, reset, true
+	}
+	return parseRemainingAndReset(header.Get(headerXRateLimitRemaining), header.Get(headerXRateLimitReset), now, true)
+}
+
+// parseRateLimitParams returns the parameters of the first policy of a
+// RateLimit field, e.g. `"default";r=50;t=30` or "limit=100, remaining=50,
+// reset=30", with lowercase names.
+func parseRateLimitParams(value string) map[string]string {
+	params := make(map[string]string)
+	for _, param := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' }) {
+		i := strings.Index(param, "=")
+		if i < 0 {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSpace(param[:i]))
+		if _, ok := params[name]; !ok {
+			params[name] = strings.Trim(strings.TrimSpace(param[i+1:]), `"`)
+		}
+	}
+	return params
+}
+
+// parseRemainingAndReset parses the remaining requests and the seconds until
+// the reset, or the Unix time of the reset if allowed and large enough.
+func parseRemainingAndReset(remainingValue, resetValue string, now time.Time, allowUnix bool) (int, time.Time, bool) {
+	if remainingValue == "" || resetValue == "" {
+		return 0, time.Time{}, false
+	}
+	remaining, err := strconv.Atoi(strings.TrimSpace(remainingValue))
+	if err != nil || remaining < 0 {
+		return 0, time.Time{}, false
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(resetValue), 64)
+	if err != nil || seconds < 0 {
+		return 0, time.Time{}, false
+	}
+	if allowUnix && seconds >= minUnixReset {
+		return remaining, time.Unix(int64(seconds), 0), true
+	}
+	return remaining, now.Add(time.Duration(seconds * float64(time.Second))), true
+}
+
+// parseRetryAfter parses the Retry-After header, given in seconds or as a
+// date.
+func parseRetryAfter(header http.Header, now time.Time) (time.Time, bool) {
+	value := strings.TrimSpace(header.Get(headerRetryAfter))
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return time.Time{}, false
+		}
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+	return parseHttpDate(header, headerRetryAfter)
+}
@@ -0,0 +1,154 @@
+package gohttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		header    http.Header
+		ok        bool
+		remaining int
+		reset     time.Time
+	}{
+		{"None", http.Header{}, false, 0, time.Time{}},
+		{"RateLimitField", http.Header{"Ratelimit": {`"default";r=50;t=30`}}, true, 50, now.Add(30 * time.Second)},
+		{"RateLimitFieldNamed", http.Header{"Ratelimit": {"limit=100, remaining=5, reset=2"}}, true, 5, now.Add(2 * time.Second)},
+		{"RateLimitFields", http.Header{"Ratelimit-Remaining": {"3"}, "Ratelimit-Reset": {"10"}}, true, 3, now.Add(10 * time.Second)},
+		{"XRateLimitSeconds", http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"60"}}, true, 0, now.Add(time.Minute)},
+		{"XRateLimitUnix", http.Header{"X-Ratelimit-Remaining": {"7"}, "X-Ratelimit-Reset": {"1767225900"}}, true, 7, time.Unix(1767225900, 0)},
+		{"MissingReset", http.Header{"X-Ratelimit-Remaining": {"7"}}, false, 0, time.Time{}},
+		{"Invalid", http.Header{"Ratelimit-Remaining": {"-1"}, "Ratelimit-Reset": {"10"}}, false, 0, time.Time{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			remaining, reset, ok := parseRateLimit(tc.header, now)
+			assert.EqualValues(t, tc.ok, ok)
+			assert.EqualValues(t, tc.remaining, remaining)
+			assert.True(t, tc.reset.Equal(reset))
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	at, ok := parseRetryAfter(http.Header{"Retry-After": {"120"}}, now)
+	assert.True(t, ok)
+	assert.EqualValues(t, now.Add(2*time.Minute), at)
+
+	at, ok = parseRetryAfter(http.Header{"Retry-After": {"Thu, 01 Jan 2026 00:05:00 GMT"}}, now)
+	assert.True(t, ok)
+	assert.True(t, now.Add(5*time.Minute).Equal(at))
+
+	_, ok = parseRetryAfter(http.Header{"Retry-After": {"soon"}}, now)
+	assert.False(t, ok)
+}
+
+func TestAdaptiveRateLimiter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newLimiter := func() *adaptiveRateLimiter {
+		l := newAdaptiveRateLimiter(2)
+		l.now = func() time.Time { return now }
+		return l
+	}
+	reserve := func(l *adaptiveRateLimiter, host string) time.Duration {
+		delay, ok := l.reserve(host, maxDuration)
+		assert.True(t, ok)
+		return delay
+	}
+
+	t.Run("Unknown", func(t *testing.T) {
+		assert.EqualValues(t, 0, reserve(newLimiter(), "api.example.com"))
+	})
+
+	t.Run("Remaining", func(t *testing.T) {
+		l := newLimiter()
+		l.update("API.example.com", http.StatusOK, http.Header{"Ratelimit": {"r=4;t=10"}})
+
+		// Above the threshold, the requests are not delayed.
+		assert.EqualValues(t, 0, reserve(l, "api.example.com"))
+		assert.EqualValues(t, 0, reserve(l, "api.example.com"))
+		// At the threshold, the requests left are spread until the reset.
+		assert.EqualValues(t, 0, reserve(l, "api.example.com"))
+		assert.EqualValues(t, 5*time.Second, reserve(l, "api.example.com"))
+		// None left, until the reset.
+		assert.EqualValues(t, 10*time.Second, reserve(l, "api.example.com"))
+		assert.EqualValues(t, 0, reserve(l, "other.example.com"))
+	})
+
+	t.Run("Exhausted", func(t *testing.T) {
+		l := newLimiter()
+		l.update("api.example.com", http.StatusOK, http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"30"}})
+		assert.EqualValues(t, 30*time.Second, reserve(l, "api.example.com"))
+
+		now = now.Add(30 * time.Second)
+		assert.EqualValues(t, 0, reserve(l, "api.example.com"))
+		assert.Empty(t, l.hosts)
+	})
+
+	t.Run("RetryAfter", func(t *testing.T) {
+		l := newLimiter()
+		l.update("api.example.com", http.StatusServiceUnavailable, http.Header{"Retry-After": {"5"}})
+		assert.EqualValues(t, 0, reserve(l, "api.example.com"))
+
+		l.update("api.example.com", http.StatusTooManyRequests, http.Header{"Retry-After": {"5"}})
+		assert.EqualValues(t, 5*time.Second, reserve(l, "api.example.com"))
+	})
+
+	t.Run("Allow", func(t *testing.T) {
+		l := newLimiter()
+		l.update("api.example.com", http.StatusOK, http.Header{"Ratelimit": {"r=2;t=10"}})
+
+		assert.True(t, l.allow("API.example.com"))
+		assert.False(t, l.allow("api.example.com"))
+		// A request not allowed is not counted.
+		assert.EqualValues(t, 1, l.hosts["api.example.com"].remaining)
+	})
+
+	t.Run("ContextDone", func(t *testing.T) {
+		l := newLimiter()
+		l.update("api.example.com", http.StatusTooManyRequests, http.Header{"Retry-After": {"60"}})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.EqualValues(t, context.DeadlineExceeded, l.wait(ctx, "api.example.com"))
+	})
+}
+
+func TestAdaptiveRateLimitClient(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewBuilder().
+		SetBaseUrl(server.URL).
+		EnableAdaptiveRateLimit(true).
+		Build()
+	assert.Nil(t, err)
+
+	response, err := client.Get("/")
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusTooManyRequests, response.StatusCode)
+
+	start := time.Now()
+	response, err = client.Get("/")
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusOK, response.StatusCode)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(900*time.Millisecond))
+}
@@ -0,0 +1,200 @@
+package gohttp
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// addressDialer resolves the host before dialing and tries every returned
+// address until a connection is established.
+//
+// Each address is given at most addressTimeout. If fallbackDelay is non-zero,
+// the next address is attempted when the previous one has not connected after
+// that delay, racing both attempts, instead of waiting for it to fail.
+// Addresses that failed are tried last during failedAddressTTL.
+type addressDialer struct {
+	dial             dialContextFunc
+	resolver         HostResolver
+	addressTimeout   time.Duration
+	fallbackDelay    time.Duration
+	failedAddressTTL time.Duration
+
+	mutex  sync.Mutex
+	failed map[string]map[string]time.Time
+	now    func() time.Time
+}
+
+type dialResult struct {
+	conn net.Conn
+	addr string
+	err  error
+}
+
+func newAddressDialer(dial dialContextFunc, resolver HostResolver) *addressDialer {
+	return &addressDialer{
+		dial:     dial,
+		resolver: resolver,
+		failed:   make(map[string]map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// DialContext connects to the given address, resolving its host first.
+func (d *addressDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.dial(ctx, network, addr)
+	}
+
+	ips, err := d.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.JoinHostPort(ip.String(), port))
+	}
+	return d.dialAddresses(ctx, network, host, d.sortByFailures(host, addrs))
+}
+
+// dialAddresses connects to the first of the addresses that answers. The
+// addresses that fail are only recorded while the context of the caller is
+// not done, as a cancelled or timed out dial says nothing about them.
+func (d *addressDialer) dialAddresses(callerCtx context.Context, network, host string, addrs []string) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(callerCtx)
+	defer cancel()
+
+	results := make(chan dialResult, len(addrs))
+	next, pending := 0, 0
+	startNext := func() {
+		addr := addrs[next]
+		next++
+		pending++
+		go func() {
+			conn, err := d.dialAddress(ctx, network, addr)
+			results <- dialResult{conn: conn, addr: addr, err: err}
+		}()
+	}
+
+	var fallback <-chan time.Time
+	if d.fallbackDelay > 0 {
+		ticker := time.NewTicker(d.fallbackDelay)
+		defer ticker.Stop()
+		fallback = ticker.C
+	}
+
+	var firstErr error
+	startNext()
+	for pending > 0 {
+		select {
+		case <-fallback:
+			if next < len(addrs) {
+				startNext()
+			}
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				d.recordSuccess(host, result.addr)
+				go closePendingConns(results, pending)
+				return result.conn, nil
+			}
+			if callerCtx.Err() == nil {
+				d.recordFailure(host, result.addr)
+			}
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			if next < len(addrs) && ctx.Err() == nil {
+				startNext()
+			}
+		}
+	}
+	return nil, firstErr
+}
+
+func (d *addressDialer) dialAddress(ctx context.Context, network, addr string) (net.Conn, error) {
+	if d.addressTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.addressTimeout)
+		defer cancel()
+	}
+	return d.dial(ctx, network, addr)
+}
+
+// closePendingConns closes the connections of the attempts that were still
+// in progress when another attempt succeeded.
+func closePendingConns(results <-chan dialResult, pending int) {
+	for ; pending > 0; pending-- {
+		if result := <-results; result.conn != nil {
+			result.conn.Close()
+		}
+	}
+}
+
+// sortByFailures moves the addresses that recently failed to the end,
+// keeping them as a last resort.
+func (d *addressDialer) sortByFailures(host string, addrs []string) []string {
+	if d.failedAddressTTL <= 0 {
+		return addrs
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	failed := d.failed[host]
+	if len(failed) == 0 {
+		return addrs
+	}
+
+	now := d.now()
+	sorted := make([]string, 0, len(addrs))
+	var last []string
+	for _, addr := range addrs {
+		if until, ok := failed[addr]; ok && now.Before(until) {
+			last = append(last, addr)
+			continue
+		}
+		sorted = append(sorted, addr)
+	}
+	return append(sorted, last...)
+}
+
+func (d *addressDialer) recordFailure(host, addr string) {
+	if d.failedAddressTTL <= 0 {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := d.now()
+	failed := d.failed[host]
+	if failed == nil {
+		failed = make(map[string]time.Time)
+		d.failed[host] = failed
+	}
+	for a, until := range failed {
+		if !now.Before(until) {
+			delete(failed, a)
+		}
+	}
+	failed[addr] = now.Add(d.failedAddressTTL)
+}
+
+func (d *addressDialer) recordSuccess(host, addr string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if failed := d.failed[host]; failed != nil {
+		delete(failed, addr)
+		if len(failed) == 0 {
+			delete(d.failed, host)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package gohttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dialMock connects to the addresses marked as up, fails the ones marked
+// as down and blocks on any other address until the context is done.
+type dialMock struct {
+	mutex  sync.Mutex
+	up     map[string]bool
+	down   map[string]bool
+	dialed []string
+}
+
+func (d *dialMock) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.mutex.Lock()
+	d.dialed = append(d.dialed, addr)
+	up, down := d.up[addr], d.down[addr]
+	d.mutex.Unlock()
+
+	switch {
+	case up:
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	case down:
+		return nil, errors.New("connection refused")
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (d *dialMock) getDialed() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]string(nil), d.dialed...)
+}
+
+func TestAddressDialer(t *testing.T) {
+	ctx := context.Background()
+	resolver := newResolverMock("api.example.com", "10.0.0.1", "10.0.0.2")
+
+	t.Run("FailoverToNextAddress", func(t *testing.T) {
+		dial := &dialMock{
+			down: map[string]bool{"10.0.0.1:443": true},
+			up:   map[string]bool{"10.0.0.2:443": true},
+		}
+		dialer := newAddressDialer(dial.DialContext, resolver)
+		dialer.failedAddressTTL = time.Minute
+
+		conn, err := dialer.DialContext(ctx, "tcp", "api.example.com:443")
+		assert.Nil(t, err)
+		assert.NotNil(t, conn)
+		assert.EqualValues(t, []string{"10.0.0.1:443", "10.0.0.2:443"}, dial.getDialed())
+
+		// The failed address is skipped until its ttl expires:
+		conn, err = dialer.DialContext(ctx, "tcp", "api.example.com:443")
+		assert.Nil(t, err)
+		assert.NotNil(t, conn)
+		assert.EqualValues(t, []string{"10.0.0.1:443", "10.0.0.2:443", "10.0.0.2:443"}, dial.getDialed())
+	})
+
+	t.Run("CallerContextDone", func(t *testing.T) {
+		dial := &dialMock{up: map[string]bool{"10.0.0.2:443": true}}
+		dialer := newAddressDialer(dial.DialContext, resolver)
+		dialer.failedAddressTTL = time.Minute
+
+		timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := dialer.DialContext(timeout, "tcp", "api.example.com:443")
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+		// The address is still tried first:
+		assert.EqualValues(t, []string{"10.0.0.1:443", "10.0.0.2:443"}, dialer.sortByFailures("api.example.com", []string{"10.0.0.1:443", "10.0.0.2:443"}))
+	})
+
+	t.Run("AllAddressesFail", func(t *testing.T) {
+		dial := &dialMock{down: map[string]bool{"10.0.0.1:443": true, "10.0.0.2:443": true}}
+		dialer := newAddressDialer(dial.DialContext, resolver)
+
+		conn, err := dialer.DialContext(ctx, "tcp", "api.example.com:443")
+
+		assert.Nil(t, conn)
+		assert.EqualValues(t, "connection refused", err.Error())
+		assert.EqualValues(t, 2, len(dial.getDialed()))
+	})
+
+	t.Run("AddressTimeout", func(t *testing.T) {
+		dial := &dialMock{up: map[string]bool{"10.0.0.2:443": true}}
+		dialer := newAddressDialer(dial.DialContext, resolver)
+		dialer.addressTimeout = 20 * time.Millisecond
+
+		start := time.Now()
+		conn, err := dialer.DialContext(ctx, "tcp", "api.example.com:443")
+
+		assert.Nil(t, err)
+		assert.NotNil(t, conn)
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	})
+
+	t.Run("FallbackDelay", func(t *testing.T) {
+		dial := &dialMock{up: map[string]bool{"10.0.0.2:443": true}}
+		dialer := newAddressDialer(dial.DialContext, resolver)
+		dialer.fallbackDelay = 10 * time.Millisecond
+
+		start := time.Now()
+		conn, err := dialer.DialContext(ctx, "tcp", "api.example.com:443")
+
+		assert.Nil(t, err)
+		assert.NotNil(t, conn)
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+		assert.EqualValues(t, []string{"10.0.0.1:443", "10.0.0.2:443"}, dial.getDialed())
+	})
+
+	t.Run("IPAddressIsNotResolved", func(t *testing.T) {
+		dial := &dialMock{up: map[string]bool{"10.0.0.9:443": true}}
+		dialer := newAddressDialer(dial.DialContext, resolver)
+
+		conn, err := dialer.DialContext(ctx, "tcp", "10.0.0.9:443")
+
+		assert.Nil(t, err)
+		assert.NotNil(t, conn)
+	})
+}
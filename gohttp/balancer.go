@@ -0,0 +1,314 @@
+package gohttp
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getmiranda/go-httpclient/core"
+)
+
+// LoadBalancer is the interface that selects the endpoint used for a request
+// among the available ones.
+type LoadBalancer interface {
+	// Pick returns one of the given endpoints. It is never called with an
+	// empty list and must be safe for concurrent use.
+	Pick(endpoints []*Endpoint) *Endpoint
+}
+
+// Endpoint is one of the base urls the client balances the requests across.
+type Endpoint struct {
+	inFlight int64
+
+	baseUrl string
+
+	mutex          sync.Mutex
+	failures       int
+	ejectedUntil   time.Time
+	unhealthy      bool
+	checkSuccesses int
+	checkFailures  int
+	lastCheck      time.Time
+	lastCheckErr   error
+}
+
+// BaseUrl returns the base url of the endpoint.
+func (e *Endpoint) BaseUrl() string {
+	return e.baseUrl
+}
+
+// InFlight returns the number of requests being sent to the endpoint.
+func (e *Endpoint) InFlight() int {
+	return int(atomic.LoadInt64(&e.inFlight))
+}
+
+// isAvailable checks whether the endpoint is healthy and not ejected.
+func (e *Endpoint) isAvailable(now time.Time) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return !e.unhealthy && !now.Before(e.ejectedUntil)
+}
+
+type roundRobinBalancer struct {
+	next uint64
+}
+
+// NewRoundRobinBalancer returns a LoadBalancer that picks the endpoints in turn.
+func NewRoundRobinBalancer() LoadBalancer {
+	return &roundRobinBalancer{}
+}
+
+func (b *roundRobinBalancer) Pick(endpoints []*Endpoint) *Endpoint {
+	next := atomic.AddUint64(&b.next, 1) - 1
+	return endpoints[next%uint64(len(endpoints))]
+}
+
+type randomBalancer struct {
+	mutex  sync.Mutex
+	random *rand.Rand
+}
+
+// NewRandomBalancer returns a LoadBalancer that picks a random endpoint.
+func NewRandomBalancer() LoadBalancer {
+	return &randomBalancer{random: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (b *randomBalancer) Pick(endpoints []*Endpoint) *Endpoint {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return endpoints[b.random.Intn(len(endpoints))]
+}
+
+type leastInFlightBalancer struct {
+	next uint64
+}
+
+// NewLeastInFlightBalancer returns a LoadBalancer that picks the endpoint with
+// the fewest requests in flight, taking turns between the tied ones.
+func NewLeastInFlightBalancer() LoadBalancer {
+	return &leastInFlightBalancer{}
+}
+
+func (b *leastInFlightBalancer) Pick(endpoints []*Endpoint) *Endpoint {
+	offset := int(atomic.AddUint64(&b.next, 1) % uint64(len(endpoints)))
+	var picked *Endpoint
+	for i := range endpoints {
+		endpoint := endpoints[(i+offset)%len(endpoints)]
+		if picked == nil || endpoint.InFlight() < picked.InFlight() {
+			picked = endpoint
+		}
+	}
+	return picked
+}
+
+type weightedBalancer struct {
+	weights map[string]int
+
+	mutex  sync.Mutex
+	random *rand.Rand
+}
+
+// NewWeightedBalancer returns a LoadBalancer that picks a random endpoint
+// with a probability proportional to its weight. The weights are given
+// by base url, endpoints without a weight have a weight of 1.
+func NewWeightedBalancer(weights map[string]int) LoadBalancer {
+	return &weightedBalancer{
+		weights: weights,
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (b *weightedBalancer) getWeight(endpoint *Endpoint) int {
+	weight, ok := b.weights[endpoint.BaseUrl()]
+	if !ok {
+		return 1
+	}
+	if weight < 0 {
+		return 0
+	}
+	return weight
+}
+
+func (b *weightedBalancer) Pick(endpoints []*Endpoint) *Endpoint {
+	total := 0
+	for _, endpoint := range endpoints {
+		total += b.getWeight(endpoint)
+	}
+	if total == 0 {
+		return endpoints[0]
+	}
+
+	b.mutex.Lock()
+	n := b.random.Intn(total)
+	b.mutex.Unlock()
+
+	for _, endpoint := range endpoints {
+		if n -= b.getWeight(endpoint); n < 0 {
+			return endpoint
+		}
+	}
+	return endpoints[len(endpoints)-1]
+}
+
+// endpointPool keeps the endpoints of the client and the state used to
+// passively eject the ones failing repeatedly.
+type endpointPool struct {
+	mutex         sync.RWMutex
+	endpoints     []*Endpoint
+	balancer      LoadBalancer
+	ejectFailures int
+	ejectDuration time.Duration
+	now           func() time.Time
+}
+
+func newEndpointPool(baseUrls []string, balancer LoadBalancer) *endpointPool {
+	if balancer == nil {
+		balancer = NewRoundRobinBalancer()
+	}
+	pool := &endpointPool{
+		balancer: balancer,
+		now:      time.Now,
+	}
+	pool.setBaseUrls(baseUrls)
+	return pool
+}
+
+func (p *endpointPool) getEndpoints() []*Endpoint {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.endpoints
+}
+
+// setBaseUrls replaces the endpoints of the pool, keeping the state of the
+// ones whose base url did not change.
+func (p *endpointPool) setBaseUrls(baseUrls []string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	current := make(map[string]*Endpoint, len(p.endpoints))
+	for _, endpoint := range p.endpoints {
+		current[endpoint.baseUrl] = endpoint
+	}
+
+	endpoints := make([]*Endpoint, 0, len(baseUrls))
+	for _, baseUrl := range baseUrls {
+		endpoint, ok := current[baseUrl]
+		if !ok {
+			endpoint = &Endpoint{baseUrl: baseUrl}
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	p.endpoints = endpoints
+}
+
+// pick returns the endpoint for the next attempt of a request, skipping the
+// ones already tried and the unavailable ones. If no endpoint is available,
+// the first attempt is balanced across all of them.
+func (p *endpointPool) pick(tried map[*Endpoint]bool) *Endpoint {
+	now := p.now()
+	endpoints := p.getEndpoints()
+	var candidates []*Endpoint
+	for _, endpoint := range endpoints {
+		if !tried[endpoint] && endpoint.isAvailable(now) {
+			candidates = append(candidates, endpoint)
+		}
+	}
+	if len(candidates) == 0 && len(tried) == 0 {
+		candidates = endpoints
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	return p.balancer.Pick(candidates)
+}
+
+func (p *endpointPool) start(endpoint *Endpoint) {
+	atomic.AddInt64(&endpoint.inFlight, 1)
+}
+
+// done records the result of a request sent to the endpoint, ejecting it
+// after ejectFailures consecutive failures.
+func (p *endpointPool) done(endpoint *Endpoint, failed bool) {
+	atomic.AddInt64(&endpoint.inFlight, -1)
+
+	endpoint.mutex.Lock()
+	defer endpoint.mutex.Unlock()
+
+	if !failed {
+		endpoint.failures = 0
+		return
+	}
+	endpoint.failures++
+	if p.ejectFailures > 0 && endpoint.failures >= p.ejectFailures {
+		endpoint.failures = 0
+		endpoint.ejectedUntil = p.now().Add(p.ejectDuration)
+	}
+}
+
+// cancel records a request sent to the endpoint that says nothing about
+// its health, such as one stopped by the caller.
+func (p *endpointPool) cancel(endpoint *Endpoint) {
+	atomic.AddInt64(&endpoint.inFlight, -1)
+}
+
+// doBalanced sends the request to one of the endpoints of the pool.
+// Idempotent requests that fail are sent again to another endpoint until
+// every endpoint has been tried.
+func (c *httpClient) doBalanced(request *request, pool *endpointPool) (*core.Response, error) {
+	tried := make(map[*Endpoint]bool)
+	var response *core.Response
+	var err error
+
+	for endpoint := pool.pick(tried); endpoint != nil; endpoint = pool.pick(tried) {
+		tried[endpoint] = true
+
+		req, reqErr := c.getRequest(request, endpoint.BaseUrl())
+		if reqErr != nil {
+			return nil, reqErr
+		}
+
+		pool.start(endpoint)
+		response, err = c.execute(request, req)
+		if err != nil && isLocalError(req, err) {
+			pool.cancel(endpoint)
+			break
+		}
+		failed := err != nil || isEndpointFailure(response.StatusCode)
+		pool.done(endpoint, failed)
+
+		if !failed || !isIdempotent(req.Method) {
+			break
+		}
+	}
+	return response, err
+}
+
+// isLocalError checks whether the request failed because of the client
+// rather than the endpoint, e.g. its context is done or its concurrency
+// or rate limits are reached, so the endpoint is neither marked as failing
+// nor replaced by another one.
+func isLocalError(req *http.Request, err error) bool {
+	return req.Context().Err() != nil || errors.Is(err, ErrBulkheadFull) || errors.Is(err, ErrRateLimited)
+}
+
+// isEndpointFailure checks whether the status code means the endpoint is
+// failing, rather than the request being invalid.
+func isEndpointFailure(statusCode int) bool {
+	return statusCode == http.StatusBadGateway ||
+		statusCode == http.StatusServiceUnavailable ||
+		statusCode == http.StatusGatewayTimeout
+}
+
+// isIdempotent checks whether a request with the given method can be safely
+// sent more than once.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
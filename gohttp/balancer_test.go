@@ -0,0 +1,188 @@
+package gohttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEndpoints(baseUrls ...string) []*Endpoint {
+	return newEndpointPool(baseUrls, nil).endpoints
+}
+
+func TestLoadBalancers(t *testing.T) {
+	endpoints := newTestEndpoints("http://a", "http://b", "http://c")
+
+	t.Run("RoundRobin", func(t *testing.T) {
+		balancer := NewRoundRobinBalancer()
+
+		var picked []string
+		for i := 0; i < 4; i++ {
+			picked = append(picked, balancer.Pick(endpoints).BaseUrl())
+		}
+
+		assert.EqualValues(t, []string{"http://a", "http://b", "http://c", "http://a"}, picked)
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		balancer := NewRandomBalancer()
+
+		for i := 0; i < 10; i++ {
+			assert.Contains(t, endpoints, balancer.Pick(endpoints))
+		}
+	})
+
+	t.Run("LeastInFlight", func(t *testing.T) {
+		balancer := NewLeastInFlightBalancer()
+		endpoints := newTestEndpoints("http://a", "http://b", "http://c")
+		endpoints[0].inFlight = 2
+		endpoints[1].inFlight = 1
+		endpoints[2].inFlight = 3
+
+		assert.EqualValues(t, "http://b", balancer.Pick(endpoints).BaseUrl())
+	})
+
+	t.Run("Weighted", func(t *testing.T) {
+		balancer := NewWeightedBalancer(map[string]int{"http://a": 0, "http://b": 3})
+
+		counts := make(map[string]int)
+		for i := 0; i < 100; i++ {
+			counts[balancer.Pick(endpoints).BaseUrl()]++
+		}
+
+		assert.EqualValues(t, 0, counts["http://a"])
+		assert.Greater(t, counts["http://b"], counts["http://c"])
+	})
+}
+
+func TestEndpointPool(t *testing.T) {
+	t.Run("Ejection", func(t *testing.T) {
+		pool := newEndpointPool([]string{"http://a", "http://b"}, nil)
+		pool.ejectFailures = 2
+		pool.ejectDuration = time.Minute
+		now := time.Now()
+		pool.now = func() time.Time { return now }
+		a := pool.endpoints[0]
+
+		pool.done(a, true)
+		assert.True(t, a.isAvailable(now))
+		pool.done(a, true)
+		assert.False(t, a.isAvailable(now))
+
+		for i := 0; i < 3; i++ {
+			assert.EqualValues(t, "http://b", pool.pick(map[*Endpoint]bool{}).BaseUrl())
+		}
+
+		now = now.Add(time.Minute)
+		assert.True(t, a.isAvailable(now))
+	})
+
+	t.Run("AllEjected", func(t *testing.T) {
+		pool := newEndpointPool([]string{"http://a"}, nil)
+		pool.endpoints[0].ejectedUntil = time.Now().Add(time.Minute)
+
+		assert.NotNil(t, pool.pick(map[*Endpoint]bool{}))
+		assert.Nil(t, pool.pick(map[*Endpoint]bool{pool.endpoints[0]: true}))
+	})
+}
+
+func TestBaseUrls(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("healthy " + r.URL.Path))
+	}))
+	defer healthy.Close()
+
+	t.Run("FailoverIdempotentRequests", func(t *testing.T) {
+		client, err := NewBuilder().SetBaseUrls([]string{failing.URL, healthy.URL}).Build()
+		assert.Nil(t, err)
+
+		for i := 0; i < 2; i++ {
+			response, err := client.Get("/users")
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusOK, response.StatusCode)
+			assert.EqualValues(t, "healthy /users", response.String())
+		}
+	})
+
+	t.Run("FailoverDoRequests", func(t *testing.T) {
+		client, err := NewBuilder().SetBaseUrls([]string{failing.URL, healthy.URL}).Build()
+		assert.Nil(t, err)
+
+		for i := 0; i < 2; i++ {
+			req, _ := http.NewRequest(http.MethodGet, "/users", nil)
+			response, err := client.Do(req, WithClientDefaults())
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusOK, response.StatusCode)
+			assert.EqualValues(t, "healthy /users", response.String())
+		}
+	})
+
+	t.Run("NoFailoverForPost", func(t *testing.T) {
+		client, err := NewBuilder().SetBaseUrls([]string{failing.URL, healthy.URL}).Build()
+		assert.Nil(t, err)
+
+		response, err := client.Post("/users", nil)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusServiceUnavailable, response.StatusCode)
+	})
+
+	t.Run("CallerContextDone", func(t *testing.T) {
+		client, err := NewBuilder().
+			SetBaseUrls([]string{healthy.URL, healthy.URL + "/v2"}).
+			SetEndpointEjection(1, time.Minute).
+			Build()
+		assert.Nil(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = client.Get("/users", WithContext(ctx))
+
+		assert.True(t, errors.Is(err, context.Canceled))
+		for _, status := range client.Endpoints() {
+			assert.False(t, status.Ejected)
+			assert.EqualValues(t, 0, status.InFlight)
+		}
+	})
+
+	t.Run("AbsoluteUrl", func(t *testing.T) {
+		var hits int32
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer other.Close()
+
+		client, err := NewBuilder().SetBaseUrls([]string{healthy.URL, healthy.URL + "/v2"}).Build()
+		assert.Nil(t, err)
+
+		response, err := client.Get(other.URL + "/users")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusServiceUnavailable, response.StatusCode)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&hits))
+		for _, status := range client.Endpoints() {
+			assert.False(t, status.Ejected)
+		}
+	})
+
+	t.Run("BaseUrlAndBaseUrls", func(t *testing.T) {
+		_, err := NewBuilder().
+			SetBaseUrl(healthy.URL).
+			SetBaseUrls([]string{failing.URL, healthy.URL}).
+			Build()
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "base url must be set either from a single url or from several urls, not both", err.Error())
+	})
+}
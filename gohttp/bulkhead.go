@@ -0,0 +1,229 @@
+package gohttp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBulkheadFull is returned when a request can not be sent because the
+// maximum number of requests in flight is reached and the wait queue is
+// full, or the request waited in the queue for longer than its timeout.
+var ErrBulkheadFull = errors.New("bulkhead full: too many requests in flight")
+
+// BulkheadStats holds the current state of the concurrency limits of the
+// client.
+type BulkheadStats struct {
+	// InFlight is the number of requests being sent.
+	InFlight int
+	// Queued is the number of requests waiting for a free slot.
+	Queued int
+	// Rejected is the number of requests failed with ErrBulkheadFull.
+	Rejected int64
+	// Hosts holds the state of each host with requests in flight or queued.
+	Hosts map[string]BulkheadHostStats
+}
+
+// BulkheadHostStats holds the current state of the concurrency limit of a
+// host.
+type BulkheadHostStats struct {
+	// InFlight is the number of requests being sent to the host.
+	InFlight int
+	// Queued is the number of requests waiting for a free slot of the host.
+	Queued int
+}
+
+type hostBulkhead struct {
+	slots    chan struct{}
+	inFlight int
+	queued   int
+	// users is the number of requests holding or waiting for a slot.
+	users int
+}
+
+// bulkhead limits the number of requests in flight, client-wide and per
+// host. Requests over the limits wait in a queue of queueSize requests for
+// up to queueTimeout, zero meaning no timeout.
+type bulkhead struct {
+	maxPerHost   int
+	queueSize    int
+	queueTimeout time.Duration
+	slots        chan struct{}
+
+	mutex    sync.Mutex
+	inFlight int
+	queued   int
+	rejected int64
+	hosts    map[string]*hostBulkhead
+}
+
+func newBulkhead(maxInFlight, maxPerHost, queueSize int, queueTimeout time.Duration) *bulkhead {
+	b := &bulkhead{
+		maxPerHost:   maxPerHost,
+		queueSize:    queueSize,
+		queueTimeout: queueTimeout,
+		hosts:        make(map[string]*hostBulkhead),
+	}
+	if maxInFlight > 0 {
+		b.slots = make(chan struct{}, maxInFlight)
+	}
+	return b
+}
+
+// acquire takes a slot of the client and of the given host, waiting in the
+// queue if needed, and returns the function releasing them.
+func (b *bulkhead) acquire(ctx context.Context, host string) (func(), error) {
+	host = strings.ToLower(host)
+	b.mutex.Lock()
+	h, ok := b.hosts[host]
+	if !ok {
+		h = &hostBulkhead{}
+		if b.maxPerHost > 0 {
+			h.slots = make(chan struct{}, b.maxPerHost)
+		}
+		b.hosts[host] = h
+	}
+	h.users++
+	b.mutex.Unlock()
+
+	acquired := tryAcquireSlot(h.slots)
+	if acquired && !tryAcquireSlot(b.slots) {
+		releaseSlot(h.slots)
+		acquired = false
+	}
+	if !acquired {
+		if err := b.wait(ctx, h); err != nil {
+			b.mutex.Lock()
+			b.releaseHost(host, h)
+			b.mutex.Unlock()
+			return nil, err
+		}
+	}
+
+	b.mutex.Lock()
+	b.inFlight++
+	h.inFlight++
+	b.mutex.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			releaseSlot(b.slots)
+			releaseSlot(h.slots)
+			b.mutex.Lock()
+			b.inFlight--
+			h.inFlight--
+			b.releaseHost(host, h)
+			b.mutex.Unlock()
+		})
+	}, nil
+}
+
+// wait queues the request until it gets a slot of the host and then of
+// the client, so a busy host does not hold the slots of the client.
+func (b *bulkhead) wait(ctx context.Context, h *hostBulkhead) error {
+	b.mutex.Lock()
+	if b.queued >= b.queueSize {
+		b.rejected++
+		b.mutex.Unlock()
+		return ErrBulkheadFull
+	}
+	b.queued++
+	h.queued++
+	b.mutex.Unlock()
+
+	defer func() {
+		b.mutex.Lock()
+		b.queued--
+		h.queued--
+		b.mutex.Unlock()
+	}()
+
+	var timeout <-chan time.Time
+	if b.queueTimeout > 0 {
+		timer := time.NewTimer(b.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	err := acquireSlot(ctx, h.slots, timeout)
+	if err == nil {
+		if err = acquireSlot(ctx, b.slots, timeout); err != nil {
+			releaseSlot(h.slots)
+		}
+	}
+	if err == ErrBulkheadFull {
+		b.mutex.Lock()
+		b.rejected++
+		b.mutex.Unlock()
+	}
+	return err
+}
+
+// releaseHost forgets the host once no request holds or waits for its
+// slots. It must be called with the mutex held.
+func (b *bulkhead) releaseHost(host string, h *hostBulkhead) {
+	h.users--
+	if h.users == 0 {
+		delete(b.hosts, host)
+	}
+}
+
+func (b *bulkhead) stats() BulkheadStats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	stats := BulkheadStats{
+		InFlight: b.inFlight,
+		Queued:   b.queued,
+		Rejected: b.rejected,
+		Hosts:    make(map[string]BulkheadHostStats, len(b.hosts)),
+	}
+	for host, h := range b.hosts {
+		stats.Hosts[host] = BulkheadHostStats{InFlight: h.inFlight, Queued: h.queued}
+	}
+	return stats
+}
+
+func tryAcquireSlot(slots chan struct{}) bool {
+	if slots == nil {
+		return true
+	}
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func acquireSlot(ctx context.Context, slots chan struct{}, timeout <-chan time.Time) error {
+	if slots == nil {
+		return nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout:
+		return ErrBulkheadFull
+	}
+}
+
+func releaseSlot(slots chan struct{}) {
+	if slots != nil {
+		<-slots
+	}
+}
+
+// BulkheadStats returns the current state of the concurrency limits set
+// with SetMaxInFlight and SetMaxInFlightPerHost.
+func (c *httpClient) BulkheadStats() BulkheadStats {
+	if c.bulkhead == nil {
+		return BulkheadStats{}
+	}
+	return c.bulkhead.stats()
+}
@@ -0,0 +1,158 @@
+package gohttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkhead(t *testing.T) {
+	t.Run("QueueFull", func(t *testing.T) {
+		b := newBulkhead(1, 0, 0, 0)
+		release, err := b.acquire(context.Background(), "a.example.com")
+		assert.Nil(t, err)
+
+		_, err = b.acquire(context.Background(), "b.example.com")
+		assert.True(t, errors.Is(err, ErrBulkheadFull))
+
+		release()
+		release()
+		release, err = b.acquire(context.Background(), "b.example.com")
+		assert.Nil(t, err)
+		release()
+		assert.EqualValues(t, BulkheadStats{Rejected: 1, Hosts: map[string]BulkheadHostStats{}}, b.stats())
+	})
+
+	t.Run("QueueTimeout", func(t *testing.T) {
+		b := newBulkhead(1, 0, 1, 10*time.Millisecond)
+		release, _ := b.acquire(context.Background(), "a.example.com")
+		defer release()
+
+		start := time.Now()
+		_, err := b.acquire(context.Background(), "a.example.com")
+
+		assert.EqualValues(t, ErrBulkheadFull, err)
+		assert.GreaterOrEqual(t, int64(time.Since(start)), int64(10*time.Millisecond))
+	})
+
+	t.Run("ContextDone", func(t *testing.T) {
+		b := newBulkhead(1, 0, 1, 0)
+		release, _ := b.acquire(context.Background(), "a.example.com")
+		defer release()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := b.acquire(ctx, "a.example.com")
+
+		assert.EqualValues(t, context.DeadlineExceeded, err)
+		assert.EqualValues(t, 0, b.stats().Rejected)
+	})
+
+	t.Run("PerHost", func(t *testing.T) {
+		b := newBulkhead(0, 1, 1, 0)
+		releaseA, err := b.acquire(context.Background(), "A.example.com")
+		assert.Nil(t, err)
+		releaseB, err := b.acquire(context.Background(), "b.example.com")
+		assert.Nil(t, err)
+		defer releaseB()
+
+		acquired := make(chan struct{})
+		go func() {
+			release, err := b.acquire(context.Background(), "a.example.com")
+			assert.Nil(t, err)
+			release()
+			close(acquired)
+		}()
+		assert.Eventually(t, func() bool {
+			return b.stats().Queued == 1
+		}, time.Second, time.Millisecond)
+
+		stats := b.stats()
+		assert.EqualValues(t, 2, stats.InFlight)
+		assert.EqualValues(t, BulkheadHostStats{InFlight: 1, Queued: 1}, stats.Hosts["a.example.com"])
+		assert.EqualValues(t, BulkheadHostStats{InFlight: 1}, stats.Hosts["b.example.com"])
+
+		releaseA()
+		<-acquired
+	})
+}
+
+func TestBulkheadClient(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	client, err := NewBuilder().
+		SetBaseUrl(server.URL).
+		SetMaxInFlightPerHost(2).
+		SetBulkheadQueue(1, 0).
+		Build()
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Get("/")
+			assert.Nil(t, err)
+		}()
+	}
+	assert.Eventually(t, func() bool {
+		stats := client.BulkheadStats()
+		return stats.InFlight == 2 && stats.Queued == 1
+	}, time.Second, time.Millisecond)
+
+	_, err = client.Get("/")
+	assert.EqualValues(t, ErrBulkheadFull, err)
+
+	close(release)
+	wg.Wait()
+	assert.EqualValues(t, BulkheadStats{Rejected: 1, Hosts: map[string]BulkheadHostStats{}}, client.BulkheadStats())
+}
+
+func TestBulkheadBaseUrls(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		<-release
+	}))
+	defer server.Close()
+
+	client, err := NewBuilder().
+		SetBaseUrls([]string{server.URL, server.URL + "/v2"}).
+		SetEndpointEjection(1, time.Minute).
+		SetMaxInFlight(1).
+		Build()
+	assert.Nil(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := client.Get("/")
+		assert.Nil(t, err)
+	}()
+	assert.Eventually(t, func() bool {
+		return client.BulkheadStats().InFlight == 1
+	}, time.Second, time.Millisecond)
+
+	_, err = client.Get("/")
+	assert.EqualValues(t, ErrBulkheadFull, err)
+	close(release)
+	<-done
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&hits))
+	for _, status := range client.Endpoints() {
+		assert.False(t, status.Ejected)
+	}
+}
@@ -0,0 +1,471 @@
+package gohttp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getmiranda/go-httpclient/core"
+)
+
+// cacheEntry is a response stored in the response cache.
+type cacheEntry struct {
+	StatusCode int
+	Status     string
+	Proto      string
+	Header     http.Header
+	Body       []byte
+	// RequestHeader holds the values of the request headers the response
+	// varies on.
+	RequestHeader http.Header
+	RequestTime   time.Time
+	ResponseTime  time.Time
+}
+
+func newCacheEntry(req *http.Request, response *core.Response, requestTime, responseTime time.Time) *cacheEntry {
+	requestHeader := make(http.Header)
+	for _, field := range getVaryFields(response.Header) {
+		if values := req.Header.Values(field); len(values) > 0 {
+			requestHeader[field] = append([]string(nil), values...)
+		}
+	}
+	return &cacheEntry{
+		StatusCode:    response.StatusCode,
+		Status:        response.Status,
+		Proto:         response.Proto,
+		Header:        response.Header.Clone(),
+		Body:          append([]byte(nil), response.Bytes()...),
+		RequestHeader: requestHeader,
+		RequestTime:   requestTime,
+		ResponseTime:  responseTime,
+	}
+}
+
+// freshnessLifetime returns how long the response is fresh after it was
+// generated, as described in RFC 9111 section 4.2.1.
+func (e *cacheEntry) freshnessLifetime(shared bool) time.Duration {
+	cc := parseCacheControl(e.Header)
+	if shared {
+		if lifetime, ok := cc.duration("s-maxage"); ok {
+			return lifetime
+		}
+	}
+	if lifetime, ok := cc.duration("max-age"); ok {
+		return lifetime
+	}
+
+	date, ok := parseHttpDate(e.Header, headerDate)
+	if !ok {
+		date = e.ResponseTime
+	}
+	if e.Header.Get(headerExpires) != "" {
+		// An invalid Expires header means the response is already expired.
+		expires, ok := parseHttpDate(e.Header, headerExpires)
+		if !ok || expires.Before(date) {
+			return 0
+		}
+		return expires.Sub(date)
+	}
+
+	lastModified, ok := parseHttpDate(e.Header, headerLastModified)
+	if !ok || lastModified.After(date) || !(heuristicStatusCodes[e.StatusCode] || cc.has("public")) {
+		return 0
+	}
+	lifetime := time.Duration(float64(date.Sub(lastModified)) * heuristicFraction)
+	if lifetime > maxHeuristicLifetime {
+		return maxHeuristicLifetime
+	}
+	return lifetime
+}
+
+// currentAge returns the age of the response at the given time, as
+// described in RFC 9111 section 4.2.3.
+func (e *cacheEntry) currentAge(now time.Time) time.Duration {
+	var ageValue time.Duration
+	if seconds, err := strconv.ParseInt(e.Header.Get(headerAge), 10, 64); err == nil && seconds > 0 {
+		ageValue = time.Duration(seconds) * time.Second
+	}
+
+	var apparentAge time.Duration
+	if date, ok := parseHttpDate(e.Header, headerDate); ok && e.ResponseTime.After(date) {
+		apparentAge = e.ResponseTime.Sub(date)
+	}
+
+	correctedAgeValue := ageValue + e.ResponseTime.Sub(e.RequestTime)
+	correctedInitialAge := apparentAge
+	if correctedAgeValue > correctedInitialAge {
+		correctedInitialAge = correctedAgeValue
+	}
+	return correctedInitialAge + now.Sub(e.ResponseTime)
+}
+
+// matchesVary checks whether the request has the same values as the stored
+// request for the headers the response varies on.
+func (e *cacheEntry) matchesVary(req *http.Request) bool {
+	for _, field := range getVaryFields(e.Header) {
+		if normalizeHeaderValues(req.Header.Values(field)) != normalizeHeaderValues(e.RequestHeader.Values(field)) {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeHeaderValues(values []string) string {
+	fields := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return strings.Join(fields, ", ")
+}
+
+// hasValidators checks whether the stored response can be revalidated with
+// a conditional request.
+func (e *cacheEntry) hasValidators() bool {
+	return e.Header.Get(headerETag) != "" || e.Header.Get(headerLastModified) != ""
+}
+
+// getConditionalRequest returns a copy of the request asking the server to
+// send the response only if it changed since the stored one.
+func (e *cacheEntry) getConditionalRequest(req *http.Request) *http.Request {
+	conditional := req.Clone(req.Context())
+	if etag := e.Header.Get(headerETag); etag != "" {
+		conditional.Header.Set(headerIfNoneMatch, etag)
+	}
+	if lastModified := e.Header.Get(headerLastModified); lastModified != "" {
+		conditional.Header.Set(headerIfModifiedSince, lastModified)
+	}
+	return conditional
+}
+
+// freshen updates the stored response with the headers of the 304 Not
+// Modified response validating it, as described in RFC 9111 section 4.3.4.
+func (e *cacheEntry) freshen(header http.Header, requestTime, responseTime time.Time) {
+	for name, values := range header {
+		if name == "Content-Length" {
+			continue
+		}
+		e.Header[name] = append([]string(nil), values...)
+	}
+	e.RequestTime, e.ResponseTime = requestTime, responseTime
+}
+
+// toCachedResponse returns a copy of the stored response for the given
+// request, marked as a cache hit.
+func (e *cacheEntry) toCachedResponse(req *http.Request, now time.Time) *core.Response {
+	response := e.toResponse(req, e.currentAge(now))
+	response.CacheHit = true
+	return response
+}
+
+// toResponse returns a copy of the stored response for the given request.
+func (e *cacheEntry) toResponse(req *http.Request, age time.Duration) *core.Response {
+	header := e.Header.Clone()
+	header.Set(headerAge, strconv.FormatInt(int64(age/time.Second), 10))
+
+	major, minor, ok := http.ParseHTTPVersion(e.Proto)
+	if !ok {
+		major, minor = 1, 1
+	}
+	return &core.Response{
+		BodyBytes: append([]byte(nil), e.Body...),
+		Response: &http.Response{
+			Status:        e.Status,
+			StatusCode:    e.StatusCode,
+			Proto:         e.Proto,
+			ProtoMajor:    major,
+			ProtoMinor:    minor,
+			Header:        header,
+			Body:          http.NoBody,
+			ContentLength: int64(len(e.Body)),
+			Request:       req,
+		},
+	}
+}
+
+// responseCache is a private or shared HTTP cache, as described in
+// RFC 9111, storing the responses to GET requests in a CacheStore.
+//
+// The staleWhileRevalidate and staleIfError durations, when set, replace
+// the ones given by the stored responses.
+type responseCache struct {
+	store                CacheStore
+	shared               bool
+	staleWhileRevalidate time.Duration
+	staleIfError         time.Duration
+	now                  func() time.Time
+
+	mutex        sync.Mutex
+	revalidating map[string]bool
+}
+
+func newResponseCache(store CacheStore, shared bool) *responseCache {
+	return &responseCache{
+		store:        store,
+		shared:       shared,
+		now:          time.Now,
+		revalidating: make(map[string]bool),
+	}
+}
+
+// cacheKeyHeaders are the request headers part of the cache key, so the
+// responses fetched with the credentials of a user are never served to
+// another one.
+var cacheKeyHeaders = []string{"Authorization", "Cookie"}
+
+// getCacheKey returns the key of the responses to the request: its url,
+// followed by a hash of its credentials if it has any. Requests with other
+// methods only invalidate the responses stored for their own credentials.
+func getCacheKey(req *http.Request) string {
+	var credentials strings.Builder
+	for _, header := range cacheKeyHeaders {
+		if values := req.Header.Values(header); len(values) > 0 {
+			credentials.WriteString(header)
+			credentials.WriteString(": ")
+			credentials.WriteString(strings.Join(values, ", "))
+			credentials.WriteString("\n")
+		}
+	}
+	if credentials.Len() == 0 {
+		return req.URL.String()
+	}
+	hash := sha256.Sum256([]byte(credentials.String()))
+	return req.URL.String() + " " + hex.EncodeToString(hash[:])
+}
+
+// get returns the entry stored for the given key. Entries that can not be
+// decoded, e.g. written by another version, are treated as missing.
+func (rc *responseCache) get(key string) *cacheEntry {
+	value, ok := rc.store.Get(key)
+	if !ok {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(value, &entry); err != nil {
+		rc.store.Delete(key)
+		return nil
+	}
+	return &entry
+}
+
+func (rc *responseCache) set(key string, entry *cacheEntry) {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	rc.store.Set(key, value)
+}
+
+func (rc *responseCache) delete(key string) {
+	rc.store.Delete(key)
+}
+
+// do serves the request from the cache when a fresh response is stored,
+// otherwise it sends the request, conditional when the stored response has
+// an ETag or Last-Modified header, and stores the response when allowed.
+//
+// Stale responses are served while they are revalidated in the background
+// within their stale-while-revalidate window, and instead of server errors
+// within their stale-if-error window.
+func (rc *responseCache) do(req *http.Request, send func(*http.Request) (*core.Response, error)) (*core.Response, error) {
+	key := getCacheKey(req)
+	if req.Method != http.MethodGet {
+		response, err := send(req)
+		if err == nil && !isSafeMethod(req.Method) && response.StatusCode < 400 {
+			rc.delete(key)
+		}
+		return response, err
+	}
+
+	requestTime := rc.now()
+	entry := rc.lookup(key, req)
+	if entry != nil {
+		if rc.canServe(entry, req, requestTime) {
+			return entry.toCachedResponse(req, requestTime), nil
+		}
+		if rc.canServeStale(entry, req, requestTime, rc.getStaleWhileRevalidate(entry)) {
+			response := entry.toCachedResponse(req, requestTime)
+			rc.revalidateInBackground(key, entry, req, send)
+			return response, nil
+		}
+	}
+
+	response, err := rc.fetch(key, entry, req, requestTime, send)
+	if entry != nil && isServerFailure(req, response, err) &&
+		rc.canServeStale(entry, req, requestTime, rc.getStaleIfError(entry, req)) {
+		return entry.toCachedResponse(req, rc.now()), nil
+	}
+	return response, err
+}
+
+// fetch sends the request, conditional when the stored response has an ETag
+// or Last-Modified header, and updates the cache with the response.
+func (rc *responseCache) fetch(key string, entry *cacheEntry, req *http.Request, requestTime time.Time, send func(*http.Request) (*core.Response, error)) (*core.Response, error) {
+	sent := req
+	if entry != nil && entry.hasValidators() && !isConditionalRequest(req) {
+		sent = entry.getConditionalRequest(req)
+	}
+	response, err := send(sent)
+	if err != nil {
+		return nil, err
+	}
+
+	if sent != req && response.StatusCode == http.StatusNotModified {
+		responseTime := rc.now()
+		entry.freshen(response.Header, requestTime, responseTime)
+		rc.set(key, entry)
+		revalidated := entry.toResponse(req, entry.currentAge(responseTime))
+		revalidated.Revalidated = true
+		return revalidated, nil
+	}
+
+	switch {
+	case isStorable(req, response.StatusCode, response.Header, rc.shared):
+		rc.set(key, newCacheEntry(req, response, requestTime, rc.now()))
+	case entry != nil && response.StatusCode != http.StatusNotModified && !isServerError(response.StatusCode):
+		rc.delete(key)
+	}
+	return response, nil
+}
+
+// revalidateInBackground refreshes the stored response, unless it is
+// already being refreshed. The refresh is not bound to the context of the
+// request, which may be done before it completes.
+func (rc *responseCache) revalidateInBackground(key string, entry *cacheEntry, req *http.Request, send func(*http.Request) (*core.Response, error)) {
+	rc.mutex.Lock()
+	if rc.revalidating[key] {
+		rc.mutex.Unlock()
+		return
+	}
+	rc.revalidating[key] = true
+	rc.mutex.Unlock()
+
+	background := req.Clone(context.Background())
+	go func() {
+		defer func() {
+			rc.mutex.Lock()
+			delete(rc.revalidating, key)
+			rc.mutex.Unlock()
+		}()
+		rc.fetch(key, entry, background, rc.now(), send)
+	}()
+}
+
+// lookup returns the entry stored for the request, if it can be used to
+// answer it.
+func (rc *responseCache) lookup(key string, req *http.Request) *cacheEntry {
+	if parseCacheControl(req.Header).has("no-store") {
+		return nil
+	}
+	entry := rc.get(key)
+	if entry == nil || !entry.matchesVary(req) {
+		return nil
+	}
+	return entry
+}
+
+// canServe checks whether the stored response can be served for the
+// request without contacting the server, as described in RFC 9111
+// section 4.
+func (rc *responseCache) canServe(entry *cacheEntry, req *http.Request, now time.Time) bool {
+	reqCC := parseCacheControl(req.Header)
+	if reqCC.has("no-cache") ||
+		(len(reqCC) == 0 && strings.Contains(strings.ToLower(req.Header.Get(headerPragma)), "no-cache")) {
+		return false
+	}
+	cc := parseCacheControl(entry.Header)
+	if cc.has("no-cache") {
+		return false
+	}
+
+	age := entry.currentAge(now)
+	lifetime := entry.freshnessLifetime(rc.shared)
+	if maxAge, ok := reqCC.duration("max-age"); ok && age > maxAge {
+		return false
+	}
+	if minFresh, ok := reqCC.duration("min-fresh"); ok && lifetime-age < minFresh {
+		return false
+	}
+	if age < lifetime {
+		return true
+	}
+
+	if !reqCC.has("max-stale") || cc.has("must-revalidate") || (rc.shared && cc.has("proxy-revalidate")) {
+		return false
+	}
+	maxStale, ok := reqCC.duration("max-stale")
+	return !ok || age-lifetime <= maxStale
+}
+
+// canServeStale checks whether the stored response can be served for the
+// request while it has been stale for at most maxStale, as described in
+// RFC 5861.
+func (rc *responseCache) canServeStale(entry *cacheEntry, req *http.Request, now time.Time, maxStale time.Duration) bool {
+	if maxStale <= 0 || parseCacheControl(req.Header).has("no-cache") {
+		return false
+	}
+	cc := parseCacheControl(entry.Header)
+	if cc.has("no-cache") || cc.has("must-revalidate") || (rc.shared && cc.has("proxy-revalidate")) {
+		return false
+	}
+	return entry.currentAge(now)-entry.freshnessLifetime(rc.shared) <= maxStale
+}
+
+func (rc *responseCache) getStaleWhileRevalidate(entry *cacheEntry) time.Duration {
+	if rc.staleWhileRevalidate > 0 {
+		return rc.staleWhileRevalidate
+	}
+	staleWhileRevalidate, _ := parseCacheControl(entry.Header).duration("stale-while-revalidate")
+	return staleWhileRevalidate
+}
+
+// getStaleIfError returns the longest stale-if-error duration given by the
+// stored response and the request.
+func (rc *responseCache) getStaleIfError(entry *cacheEntry, req *http.Request) time.Duration {
+	if rc.staleIfError > 0 {
+		return rc.staleIfError
+	}
+	staleIfError, _ := parseCacheControl(entry.Header).duration("stale-if-error")
+	if requested, ok := parseCacheControl(req.Header).duration("stale-if-error"); ok && requested > staleIfError {
+		return requested
+	}
+	return staleIfError
+}
+
+// isServerFailure checks whether the response is a server error, or the
+// request failed for any reason other than its context being done.
+func isServerFailure(req *http.Request, response *core.Response, err error) bool {
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	return isServerError(response.StatusCode)
+}
+
+func isServerError(statusCode int) bool {
+	return statusCode == http.StatusInternalServerError || isEndpointFailure(statusCode)
+}
+
+// isConditionalRequest checks whether the request already has its own
+// validators, so its 304 Not Modified responses are returned as they are.
+func isConditionalRequest(req *http.Request) bool {
+	return req.Header.Get(headerIfNoneMatch) != "" || req.Header.Get(headerIfModifiedSince) != ""
+}
+
+// isSafeMethod checks whether the method is read-only, so its requests do
+// not invalidate the stored responses.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
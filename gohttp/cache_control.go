@@ -0,0 +1,147 @@
+package gohttp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerCacheControl = "Cache-Control"
+	headerPragma       = "Pragma"
+	headerExpires      = "Expires"
+	headerDate         = "Date"
+	headerAge          = "Age"
+	headerVary         = "Vary"
+	headerLastModified = "Last-Modified"
+
+	headerETag            = "ETag"
+	headerIfNoneMatch     = "If-None-Match"
+	headerIfModifiedSince = "If-Modified-Since"
+
+	// heuristicFraction is the fraction of the time since the last
+	// modification used as the freshness lifetime of responses without
+	// explicit expiration, capped at maxHeuristicLifetime.
+	heuristicFraction    = 0.1
+	maxHeuristicLifetime = time.Hour * 24
+)
+
+// heuristicStatusCodes are the status codes cacheable by default, whose
+// responses can be stored without explicit expiration.
+var heuristicStatusCodes = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusPermanentRedirect:    true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// cacheControl holds the directives of the Cache-Control headers, with
+// lowercase names and unquoted values.
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := make(cacheControl)
+	for _, value := range header.Values(headerCacheControl) {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, arg := directive, ""
+			if i := strings.Index(directive, "="); i >= 0 {
+				name, arg = directive[:i], strings.Trim(strings.TrimSpace(directive[i+1:]), `"`)
+			}
+			cc[strings.ToLower(strings.TrimSpace(name))] = arg
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+// duration returns the value of a directive given in seconds.
+func (cc cacheControl) duration(directive string) (time.Duration, bool) {
+	arg, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// hasExplicitExpiration checks whether the response gives its freshness
+// lifetime, or is explicitly marked as cacheable.
+func hasExplicitExpiration(header http.Header, cc cacheControl, shared bool) bool {
+	return cc.has("max-age") || (shared && cc.has("s-maxage")) || cc.has("public") ||
+		header.Get(headerExpires) != ""
+}
+
+// isStorable checks whether the response to the given request can be
+// stored, as described in RFC 9111 section 3. Only final and complete
+// responses are stored: a 304 Not Modified only freshens a stored response.
+func isStorable(req *http.Request, statusCode int, header http.Header, shared bool) bool {
+	if req.Method != http.MethodGet || statusCode < 200 ||
+		statusCode == http.StatusPartialContent || statusCode == http.StatusNotModified {
+		return false
+	}
+
+	reqCC := parseCacheControl(req.Header)
+	cc := parseCacheControl(header)
+	if reqCC.has("no-store") || cc.has("no-store") {
+		return false
+	}
+	if shared {
+		if cc.has("private") {
+			return false
+		}
+		if req.Header.Get("Authorization") != "" &&
+			!cc.has("must-revalidate") && !cc.has("public") && !cc.has("s-maxage") {
+			return false
+		}
+	}
+	for _, field := range getVaryFields(header) {
+		if field == "*" {
+			return false
+		}
+	}
+	return heuristicStatusCodes[statusCode] || hasExplicitExpiration(header, cc, shared)
+}
+
+// getVaryFields returns the canonical names of the request headers the
+// response varies on.
+func getVaryFields(header http.Header) []string {
+	var fields []string
+	for _, value := range header.Values(headerVary) {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				fields = append(fields, http.CanonicalHeaderKey(field))
+			}
+		}
+	}
+	return fields
+}
+
+// parseHttpDate parses the value of a date header, returning false if it is
+// missing or invalid.
+func parseHttpDate(header http.Header, name string) (time.Time, bool) {
+	value := header.Get(name)
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := http.ParseTime(value)
+	return t, err == nil
+}
@@ -0,0 +1,63 @@
+package gohttp
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCacheControl(t *testing.T) {
+	header := make(http.Header)
+	header.Add(headerCacheControl, `Max-Age=60, no-cache="Set-Cookie"`)
+	header.Add(headerCacheControl, "private")
+
+	cc := parseCacheControl(header)
+
+	maxAge, ok := cc.duration("max-age")
+	assert.True(t, ok)
+	assert.EqualValues(t, time.Minute, maxAge)
+	assert.EqualValues(t, "Set-Cookie", cc["no-cache"])
+	assert.True(t, cc.has("private"))
+	assert.False(t, cc.has("public"))
+
+	_, ok = parseCacheControl(http.Header{headerCacheControl: {"max-age=-1"}}).duration("max-age")
+	assert.False(t, ok)
+}
+
+func TestIsStorable(t *testing.T) {
+	get, _ := http.NewRequest(http.MethodGet, "https://api.example.com/users", nil)
+	authorized, _ := http.NewRequest(http.MethodGet, "https://api.example.com/users", nil)
+	authorized.SetBasicAuth("john", "secret")
+	post, _ := http.NewRequest(http.MethodPost, "https://api.example.com/users", nil)
+
+	testCases := []struct {
+		name       string
+		req        *http.Request
+		statusCode int
+		header     http.Header
+		shared     bool
+		expected   bool
+	}{
+		{"HeuristicStatus", get, http.StatusOK, http.Header{}, false, true},
+		{"NotCacheableStatus", get, http.StatusCreated, http.Header{}, false, false},
+		{"ExplicitExpiration", get, http.StatusCreated, http.Header{headerCacheControl: {"max-age=60"}}, false, true},
+		{"PartialContent", get, http.StatusPartialContent, http.Header{headerCacheControl: {"max-age=60"}}, false, false},
+		{"NotModified", get, http.StatusNotModified, http.Header{headerCacheControl: {"max-age=60"}}, false, false},
+		{"Informational", get, http.StatusContinue, http.Header{headerCacheControl: {"max-age=60"}}, false, false},
+		{"Post", post, http.StatusOK, http.Header{headerCacheControl: {"max-age=60"}}, false, false},
+		{"NoStore", get, http.StatusOK, http.Header{headerCacheControl: {"no-store"}}, false, false},
+		{"VaryAll", get, http.StatusOK, http.Header{headerVary: {"*"}}, false, false},
+		{"PrivateInPrivateCache", get, http.StatusOK, http.Header{headerCacheControl: {"private"}}, false, true},
+		{"PrivateInSharedCache", get, http.StatusOK, http.Header{headerCacheControl: {"private"}}, true, false},
+		{"AuthorizationInSharedCache", authorized, http.StatusOK, http.Header{headerCacheControl: {"max-age=60"}}, true, false},
+		{"PublicAuthorizationInSharedCache", authorized, http.StatusOK, http.Header{headerCacheControl: {"public, max-age=60"}}, true, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.EqualValues(t, tc.expected, isStorable(tc.req, tc.statusCode, tc.header, tc.shared))
+		})
+	}
+}
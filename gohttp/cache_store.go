@@ -0,0 +1,278 @@
+package gohttp
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheSize = 64 << 20
+
+	diskCacheTempPrefix = ".tmp-"
+)
+
+// CacheStore is the interface used by the response cache to store the
+// encoded responses.
+//
+// Implementations must be safe for concurrent use. The values returned by
+// Get and given to Set must not be modified.
+type CacheStore interface {
+	// Get returns the value stored for the given key, if any.
+	Get(key string) ([]byte, bool)
+	// Set stores the value for the given key, replacing any previous one.
+	Set(key string, value []byte)
+	// Delete removes the value stored for the given key, if any.
+	Delete(key string)
+}
+
+type memoryCacheItem struct {
+	key   string
+	value []byte
+}
+
+// memoryCacheStore is an in-memory CacheStore, evicting the least recently
+// used values once their total size exceeds maxBytes.
+type memoryCacheStore struct {
+	maxBytes int64
+
+	mutex sync.Mutex
+	size  int64
+	items map[string]*list.Element
+	lru   *list.List
+}
+
+// NewMemoryCacheStore returns an in-memory CacheStore holding up to
+// maxBytes of keys and values, evicting the least recently used ones.
+// Values larger than maxBytes are not stored.
+func NewMemoryCacheStore(maxBytes int64) CacheStore {
+	return &memoryCacheStore{
+		maxBytes: maxBytes,
+		items:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (s *memoryCacheStore) Get(key string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	s.lru.MoveToFront(element)
+	return element.Value.(*memoryCacheItem).value, true
+}
+
+func (s *memoryCacheStore) Set(key string, value []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.remove(key)
+	size := int64(len(key) + len(value))
+	if size > s.maxBytes {
+		return
+	}
+	s.items[key] = s.lru.PushFront(&memoryCacheItem{key: key, value: value})
+	s.size += size
+	for s.size > s.maxBytes {
+		s.remove(s.lru.Back().Value.(*memoryCacheItem).key)
+	}
+}
+
+func (s *memoryCacheStore) Delete(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.remove(key)
+}
+
+func (s *memoryCacheStore) remove(key string) {
+	element, ok := s.items[key]
+	if !ok {
+		return
+	}
+	item := s.lru.Remove(element).(*memoryCacheItem)
+	delete(s.items, key)
+	s.size -= int64(len(item.key) + len(item.value))
+}
+
+type diskCacheFile struct {
+	name string
+	size int64
+}
+
+// diskCacheStore is a CacheStore keeping each value in a file of dir, named
+// after the hash of its key. Files are written atomically and the least
+// recently used ones are removed once their total size exceeds maxBytes.
+type diskCacheStore struct {
+	dir      string
+	maxBytes int64
+
+	mutex sync.Mutex
+	size  int64
+	files map[string]*list.Element
+	lru   *list.List
+}
+
+// NewDiskCacheStore returns a CacheStore keeping the values in files of the
+// given directory, which is created if needed, so they survive restarts.
+// Up to maxBytes of values are kept, removing the least recently used ones.
+//
+// The directory must only be used by a single store.
+func NewDiskCacheStore(dir string, maxBytes int64) (CacheStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	s := &diskCacheStore{
+		dir:      dir,
+		maxBytes: maxBytes,
+		files:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load indexes the files already in the directory, from the most to the
+// least recently used according to their modification time, and removes
+// the temporary files left by interrupted writes.
+func (s *diskCacheStore) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	type fileInfo struct {
+		diskCacheFile
+		modTime time.Time
+	}
+	var files []fileInfo
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(entry.Name(), diskCacheTempPrefix) {
+			os.Remove(filepath.Join(s.dir, entry.Name()))
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, fileInfo{diskCacheFile{entry.Name(), info.Size()}, info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range files {
+		file := files[i].diskCacheFile
+		s.files[file.name] = s.lru.PushBack(&file)
+		s.size += file.size
+	}
+	s.evict()
+	return nil
+}
+
+func getDiskCacheName(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func (s *diskCacheStore) Get(key string) ([]byte, bool) {
+	name := getDiskCacheName(key)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element, ok := s.files[name]
+	if !ok {
+		return nil, false
+	}
+	path := filepath.Join(s.dir, name)
+	value, err := os.ReadFile(path)
+	if err != nil {
+		s.remove(name)
+		return nil, false
+	}
+	s.lru.MoveToFront(element)
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return value, true
+}
+
+func (s *diskCacheStore) Set(key string, value []byte) {
+	name := getDiskCacheName(key)
+	size := int64(len(value))
+	if size > s.maxBytes {
+		s.Delete(key)
+		return
+	}
+
+	temp, err := os.CreateTemp(s.dir, diskCacheTempPrefix)
+	if err != nil {
+		return
+	}
+	_, err = temp.Write(value)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.Rename(temp.Name(), filepath.Join(s.dir, name)); err != nil {
+		os.Remove(temp.Name())
+		return
+	}
+	if element, ok := s.files[name]; ok {
+		s.size -= element.Value.(*diskCacheFile).size
+		s.lru.Remove(element)
+	}
+	s.files[name] = s.lru.PushFront(&diskCacheFile{name: name, size: size})
+	s.size += size
+	s.evict()
+}
+
+func (s *diskCacheStore) Delete(key string) {
+	name := getDiskCacheName(key)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.files[name]; ok {
+		os.Remove(filepath.Join(s.dir, name))
+		s.remove(name)
+	}
+}
+
+func (s *diskCacheStore) evict() {
+	for s.size > s.maxBytes {
+		name := s.lru.Back().Value.(*diskCacheFile).name
+		os.Remove(filepath.Join(s.dir, name))
+		s.remove(name)
+	}
+}
+
+func (s *diskCacheStore) remove(name string) {
+	element, ok := s.files[name]
+	if !ok {
+		return
+	}
+	file := s.lru.Remove(element).(*diskCacheFile)
+	delete(s.files, name)
+	s.size -= file.size
+}
@@ -0,0 +1,139 @@
+package gohttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCacheStore(t *testing.T, store CacheStore) {
+	t.Run("SetGetDelete", func(t *testing.T) {
+		store.Set("a", []byte("first"))
+		store.Set("a", []byte("value"))
+
+		value, ok := store.Get("a")
+		assert.True(t, ok)
+		assert.EqualValues(t, "value", value)
+
+		store.Delete("a")
+		_, ok = store.Get("a")
+		assert.False(t, ok)
+	})
+
+	t.Run("ConcurrentUse", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				key := fmt.Sprintf("key-%d", i%5)
+				store.Set(key, []byte("value"))
+				store.Get(key)
+				if i%3 == 0 {
+					store.Delete(key)
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	testCacheStore(t, NewMemoryCacheStore(1024))
+
+	t.Run("EvictLeastRecentlyUsed", func(t *testing.T) {
+		store := NewMemoryCacheStore(25)
+		store.Set("a", []byte("123456789"))
+		store.Set("b", []byte("123456789"))
+		store.Get("a")
+		store.Set("c", []byte("123456789"))
+
+		_, ok := store.Get("b")
+		assert.False(t, ok)
+		_, ok = store.Get("a")
+		assert.True(t, ok)
+		_, ok = store.Get("c")
+		assert.True(t, ok)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		store := NewMemoryCacheStore(10)
+		store.Set("a", []byte("12345678901"))
+
+		_, ok := store.Get("a")
+		assert.False(t, ok)
+	})
+}
+
+func TestDiskCacheStore(t *testing.T) {
+	store, err := NewDiskCacheStore(t.TempDir(), 1024)
+	assert.Nil(t, err)
+	testCacheStore(t, store)
+
+	t.Run("Persist", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := NewDiskCacheStore(dir, 1024)
+		assert.Nil(t, err)
+		store.Set("https://api.example.com/users", []byte("users"))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, diskCacheTempPrefix+"123"), []byte("partial"), 0600))
+
+		store, err = NewDiskCacheStore(dir, 1024)
+		assert.Nil(t, err)
+		value, ok := store.Get("https://api.example.com/users")
+		assert.True(t, ok)
+		assert.EqualValues(t, "users", value)
+
+		files, _ := os.ReadDir(dir)
+		assert.EqualValues(t, 1, len(files))
+		assert.EqualValues(t, getDiskCacheName("https://api.example.com/users"), files[0].Name())
+	})
+
+	t.Run("EvictLeastRecentlyUsed", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := NewDiskCacheStore(dir, 20)
+		assert.Nil(t, err)
+		store.Set("a", []byte("123456789"))
+		store.Set("b", []byte("123456789"))
+		store.Get("a")
+		store.Set("c", []byte("123456789"))
+
+		_, ok := store.Get("b")
+		assert.False(t, ok)
+		_, ok = store.Get("a")
+		assert.True(t, ok)
+
+		files, _ := os.ReadDir(dir)
+		assert.EqualValues(t, 2, len(files))
+	})
+}
+
+func TestCacheStoreAcrossClients(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set(headerCacheControl, "max-age=60")
+		w.Write([]byte("countries"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		store, err := NewDiskCacheStore(dir, 1<<20)
+		assert.Nil(t, err)
+		client, err := NewBuilder().SetBaseUrl(server.URL).SetCacheStore(store).Build()
+		assert.Nil(t, err)
+
+		response, err := client.Get("/countries")
+		assert.Nil(t, err)
+		assert.EqualValues(t, "countries", response.String())
+		assert.EqualValues(t, i > 0, response.CacheHit)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&hits))
+}
@@ -0,0 +1,373 @@
+package gohttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getmiranda/go-httpclient/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheEntryFreshness(t *testing.T) {
+	now := time.Date(2021, 11, 16, 10, 0, 0, 0, time.UTC)
+	newEntry := func(header http.Header) *cacheEntry {
+		header.Set(headerDate, now.Format(http.TimeFormat))
+		return &cacheEntry{StatusCode: http.StatusOK, Header: header, RequestTime: now, ResponseTime: now}
+	}
+
+	testCases := []struct {
+		name     string
+		header   http.Header
+		shared   bool
+		expected time.Duration
+	}{
+		{"MaxAge", http.Header{headerCacheControl: {"max-age=60, s-maxage=120"}}, false, time.Minute},
+		{"SharedMaxAge", http.Header{headerCacheControl: {"max-age=60, s-maxage=120"}}, true, 2 * time.Minute},
+		{"Expires", http.Header{headerExpires: {now.Add(time.Hour).Format(http.TimeFormat)}}, false, time.Hour},
+		{"InvalidExpires", http.Header{headerExpires: {"0"}}, false, 0},
+		{"Heuristic", http.Header{headerLastModified: {now.Add(-10 * time.Hour).Format(http.TimeFormat)}}, false, time.Hour},
+		{"HeuristicCap", http.Header{headerLastModified: {now.Add(-1000 * time.Hour).Format(http.TimeFormat)}}, false, maxHeuristicLifetime},
+		{"NoExpiration", http.Header{}, false, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.EqualValues(t, tc.expected, newEntry(tc.header).freshnessLifetime(tc.shared))
+		})
+	}
+
+	t.Run("CurrentAge", func(t *testing.T) {
+		entry := newEntry(http.Header{headerAge: {"30"}})
+		entry.Header.Set(headerDate, now.Add(-10*time.Second).Format(http.TimeFormat))
+		entry.RequestTime = now.Add(-2 * time.Second)
+
+		assert.EqualValues(t, 42*time.Second, entry.currentAge(now.Add(10*time.Second)))
+	})
+}
+
+func TestResponseCache(t *testing.T) {
+	var hits int32
+	now := time.Now()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set(headerDate, now.Format(http.TimeFormat))
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set(headerCacheControl, "max-age=60")
+		case "/vary":
+			w.Header().Set(headerCacheControl, "max-age=60")
+			w.Header().Set(headerVary, "Accept-Language")
+		case "/no-store":
+			w.Header().Set(headerCacheControl, "no-store")
+		case "/me":
+			w.Header().Set(headerCacheControl, "max-age=60")
+			w.Write([]byte(r.URL.Path + " " + r.Header.Get("Authorization") + r.Header.Get("Cookie")))
+			return
+		}
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("Accept-Language")))
+	}))
+	defer server.Close()
+
+	client, err := NewBuilder().SetBaseUrl(server.URL).EnableCache(true).Build()
+	assert.Nil(t, err)
+	client.(*httpClient).cache.now = func() time.Time { return now }
+
+	getHits := func(path string, opts ...RequestOption) int32 {
+		atomic.StoreInt32(&hits, 0)
+		for i := 0; i < 3; i++ {
+			response, err := client.Get(path, opts...)
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusOK, response.StatusCode)
+		}
+		return atomic.LoadInt32(&hits)
+	}
+
+	t.Run("Fresh", func(t *testing.T) {
+		assert.EqualValues(t, 1, getHits("/fresh"))
+
+		response, err := client.Get("/fresh")
+		assert.Nil(t, err)
+		assert.True(t, response.CacheHit)
+		assert.EqualValues(t, "/fresh ", response.String())
+		assert.EqualValues(t, "0", response.Header.Get(headerAge))
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		assert.EqualValues(t, 1, getHits("/fresh"))
+	})
+
+	t.Run("RequestNoCache", func(t *testing.T) {
+		assert.EqualValues(t, 3, getHits("/fresh", WithHeader(headerCacheControl, "no-cache")))
+	})
+
+	t.Run("NoStore", func(t *testing.T) {
+		assert.EqualValues(t, 3, getHits("/no-store"))
+	})
+
+	t.Run("NoExpiration", func(t *testing.T) {
+		assert.EqualValues(t, 3, getHits("/none"))
+	})
+
+	t.Run("Vary", func(t *testing.T) {
+		assert.EqualValues(t, 1, getHits("/vary", WithHeader("Accept-Language", "en")))
+		assert.EqualValues(t, 1, getHits("/vary", WithHeader("Accept-Language", "es")))
+
+		response, err := client.Get("/vary", WithHeader("Accept-Language", "es"))
+		assert.Nil(t, err)
+		assert.EqualValues(t, "/vary es", response.String())
+	})
+
+	t.Run("Credentials", func(t *testing.T) {
+		assert.EqualValues(t, 1, getHits("/me", WithBearerToken("alice")))
+		assert.EqualValues(t, 1, getHits("/me", WithBearerToken("bob")))
+
+		response, err := client.Get("/me", WithBearerToken("alice"))
+		assert.Nil(t, err)
+		assert.True(t, response.CacheHit)
+		assert.EqualValues(t, "/me Bearer alice", response.String())
+
+		response, err = client.Get("/me", WithBearerToken("bob"))
+		assert.Nil(t, err)
+		assert.True(t, response.CacheHit)
+		assert.EqualValues(t, "/me Bearer bob", response.String())
+
+		assert.EqualValues(t, 1, getHits("/me", WithHeader("Cookie", "session=alice")))
+		response, err = client.Get("/me", WithHeader("Cookie", "session=bob"))
+		assert.Nil(t, err)
+		assert.False(t, response.CacheHit)
+		assert.EqualValues(t, "/me session=bob", response.String())
+	})
+
+	t.Run("UnsafeMethodInvalidates", func(t *testing.T) {
+		response, err := client.Get("/fresh")
+		assert.Nil(t, err)
+		assert.True(t, response.CacheHit)
+
+		_, err = client.Post("/fresh", nil)
+		assert.Nil(t, err)
+
+		response, err = client.Get("/fresh")
+		assert.Nil(t, err)
+		assert.False(t, response.CacheHit)
+	})
+}
+
+func TestCacheRevalidation(t *testing.T) {
+	var hits, notModified int32
+	version := "v1"
+	lastModified := time.Date(2021, 11, 16, 10, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set(headerCacheControl, "no-cache")
+		switch r.URL.Path {
+		case "/max-age":
+			etag := `"` + version + `"`
+			w.Header().Set(headerCacheControl, "max-age=60")
+			w.Header().Set(headerETag, etag)
+			if r.Header.Get(headerIfNoneMatch) == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/etag":
+			etag := `"` + version + `"`
+			w.Header().Set(headerETag, etag)
+			if r.Header.Get(headerIfNoneMatch) == etag {
+				atomic.AddInt32(&notModified, 1)
+				w.Header().Set("X-Checked", "true")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/last-modified":
+			w.Header().Set(headerLastModified, lastModified)
+			if r.Header.Get(headerIfModifiedSince) == lastModified {
+				atomic.AddInt32(&notModified, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Write([]byte(r.URL.Path + " " + version))
+	}))
+	defer server.Close()
+
+	client, err := NewBuilder().SetBaseUrl(server.URL).EnableCache(true).Build()
+	assert.Nil(t, err)
+
+	t.Run("ETag", func(t *testing.T) {
+		response, err := client.Get("/etag")
+		assert.Nil(t, err)
+		assert.False(t, response.Revalidated)
+
+		response, err = client.Get("/etag")
+		assert.Nil(t, err)
+		assert.True(t, response.Revalidated)
+		assert.False(t, response.CacheHit)
+		assert.EqualValues(t, http.StatusOK, response.StatusCode)
+		assert.EqualValues(t, "/etag v1", response.String())
+		assert.EqualValues(t, "true", response.Header.Get("X-Checked"))
+		assert.EqualValues(t, 1, atomic.LoadInt32(&notModified))
+	})
+
+	t.Run("Changed", func(t *testing.T) {
+		version = "v2"
+		response, err := client.Get("/etag")
+		assert.Nil(t, err)
+		assert.False(t, response.Revalidated)
+		assert.EqualValues(t, "/etag v2", response.String())
+
+		response, err = client.Get("/etag")
+		assert.Nil(t, err)
+		assert.True(t, response.Revalidated)
+		assert.EqualValues(t, "/etag v2", response.String())
+	})
+
+	t.Run("LastModified", func(t *testing.T) {
+		atomic.StoreInt32(&notModified, 0)
+		client.Get("/last-modified")
+		response, err := client.Get("/last-modified")
+
+		assert.Nil(t, err)
+		assert.True(t, response.Revalidated)
+		assert.EqualValues(t, "/last-modified v2", response.String())
+		assert.EqualValues(t, 1, atomic.LoadInt32(&notModified))
+	})
+
+	t.Run("OwnValidators", func(t *testing.T) {
+		response, err := client.Get("/etag", WithHeader(headerIfNoneMatch, `"v2"`))
+
+		assert.Nil(t, err)
+		assert.False(t, response.Revalidated)
+		assert.EqualValues(t, http.StatusNotModified, response.StatusCode)
+	})
+
+	t.Run("OwnValidatorsNotStored", func(t *testing.T) {
+		response, err := client.Get("/max-age", WithHeader(headerIfNoneMatch, `"v2"`))
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusNotModified, response.StatusCode)
+
+		response, err = client.Get("/max-age")
+		assert.Nil(t, err)
+		assert.False(t, response.CacheHit)
+		assert.EqualValues(t, http.StatusOK, response.StatusCode)
+		assert.EqualValues(t, "/max-age v2", response.String())
+
+		// The stored response is kept after another conditional request.
+		client.Get("/max-age", WithHeader(headerIfNoneMatch, `"v2"`), WithHeader(headerCacheControl, "no-cache"))
+		response, err = client.Get("/max-age")
+		assert.Nil(t, err)
+		assert.True(t, response.CacheHit)
+		assert.EqualValues(t, http.StatusOK, response.StatusCode)
+		assert.EqualValues(t, "/max-age v2", response.String())
+	})
+}
+
+func TestCacheStale(t *testing.T) {
+	var hits, failing int32
+	now := time.Now()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit := atomic.AddInt32(&hits, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set(headerDate, now.Format(http.TimeFormat))
+		switch r.URL.Path {
+		case "/swr":
+			w.Header().Set(headerCacheControl, "max-age=60, stale-while-revalidate=60")
+		case "/sie":
+			w.Header().Set(headerCacheControl, "max-age=60, stale-if-error=60")
+		case "/must-revalidate":
+			w.Header().Set(headerCacheControl, "max-age=60, stale-if-error=60, must-revalidate")
+		default:
+			w.Header().Set(headerCacheControl, "max-age=60")
+		}
+		fmt.Fprintf(w, "%s %d", r.URL.Path, hit)
+	}))
+	defer server.Close()
+
+	newClient := func(staleWhileRevalidate, staleIfError time.Duration) *httpClient {
+		client, err := NewBuilder().
+			SetBaseUrl(server.URL).
+			EnableCache(true).
+			SetCacheStale(staleWhileRevalidate, staleIfError).
+			Build()
+		assert.Nil(t, err)
+		client.(*httpClient).cache.now = func() time.Time { return now }
+		return client.(*httpClient)
+	}
+	get := func(client Client, path string) *core.Response {
+		response, err := client.Get(path)
+		assert.Nil(t, err)
+		return response
+	}
+
+	t.Run("StaleWhileRevalidate", func(t *testing.T) {
+		client := newClient(0, 0)
+		atomic.StoreInt32(&hits, 0)
+		get(client, "/swr")
+		now = now.Add(90 * time.Second)
+
+		response := get(client, "/swr")
+		assert.True(t, response.CacheHit)
+		assert.EqualValues(t, "/swr 1", response.String())
+		assert.Eventually(t, func() bool {
+			return get(client, "/swr").String() == "/swr 2"
+		}, time.Second, time.Millisecond)
+
+		now = now.Add(3 * time.Minute)
+		response = get(client, "/swr")
+		assert.False(t, response.CacheHit)
+		assert.EqualValues(t, "/swr 3", response.String())
+	})
+
+	t.Run("StaleIfError", func(t *testing.T) {
+		client := newClient(0, 0)
+		atomic.StoreInt32(&hits, 0)
+		get(client, "/sie")
+		get(client, "/must-revalidate")
+		now = now.Add(90 * time.Second)
+		atomic.StoreInt32(&failing, 1)
+		defer atomic.StoreInt32(&failing, 0)
+
+		response := get(client, "/sie")
+		assert.True(t, response.CacheHit)
+		assert.EqualValues(t, "/sie 1", response.String())
+
+		response = get(client, "/must-revalidate")
+		assert.EqualValues(t, http.StatusServiceUnavailable, response.StatusCode)
+
+		now = now.Add(time.Minute)
+		response = get(client, "/sie")
+		assert.EqualValues(t, http.StatusServiceUnavailable, response.StatusCode)
+	})
+
+	t.Run("TransportError", func(t *testing.T) {
+		client := newClient(0, time.Hour)
+		down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(headerCacheControl, "max-age=60")
+			w.Write([]byte("down"))
+		}))
+		get(client, down.URL)
+		down.Close()
+		now = now.Add(2 * time.Minute)
+
+		response := get(client, down.URL)
+		assert.True(t, response.CacheHit)
+		assert.EqualValues(t, "down", response.String())
+	})
+
+	t.Run("ClientOverride", func(t *testing.T) {
+		client := newClient(time.Minute, 0)
+		atomic.StoreInt32(&hits, 0)
+		get(client, "/plain")
+		now = now.Add(90 * time.Second)
+
+		response := get(client, "/plain")
+		assert.True(t, response.CacheHit)
+		assert.EqualValues(t, "/plain 1", response.String())
+	})
+}
@@ -0,0 +1,82 @@
+package gohttp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// certificateReloader keeps a client certificate loaded from disk up to date.
+//
+// The files are checked during the TLS handshake, at most once per interval,
+// so certificates rotated by an external process are picked up by new
+// connections without rebuilding the client or dropping the connection pool.
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mutex       sync.Mutex
+	certificate *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+	lastCheck   time.Time
+}
+
+func newCertificateReloader(certFile, keyFile string, interval time.Duration) (*certificateReloader, error) {
+	r := &certificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetClientCertificate returns the current client certificate, reloading it
+// from disk first if the files changed since the last check.
+//
+// It matches the signature of tls.Config.GetClientCertificate.
+func (r *certificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if time.Since(r.lastCheck) >= r.interval {
+		// A failed reload keeps the last valid certificate. The files could
+		// be in the middle of a rotation, so they are checked again after
+		// the next interval.
+		_ = r.reload()
+	}
+	return r.certificate, nil
+}
+
+// reload loads the certificate if any of the files changed since it was
+// last loaded. It must be called with the mutex held.
+func (r *certificateReloader) reload() error {
+	r.lastCheck = time.Now()
+
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("error loading client certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("error loading client certificate: %w", err)
+	}
+	if r.certificate != nil && certInfo.ModTime().Equal(r.certModTime) && keyInfo.ModTime().Equal(r.keyModTime) {
+		return nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("error loading client certificate: %w", err)
+	}
+	r.certificate = &certificate
+	r.certModTime = certInfo.ModTime()
+	r.keyModTime = keyInfo.ModTime()
+	return nil
+}
@@ -0,0 +1,88 @@
+package gohttp
+
+import (
+	"crypto/x509"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificateReloader(t *testing.T) {
+	ca := newTestCertificate(t, "test-ca", nil)
+	server := newMutualTLSServer(t, ca)
+
+	rotate := func(t *testing.T, certFile, keyFile string, cert *testCertificate, modTime time.Time) {
+		writeTestFile(t, "", certFile, cert.certPEM)
+		writeTestFile(t, "", keyFile, cert.keyPEM)
+		assert.Nil(t, os.Chtimes(certFile, modTime, modTime))
+		assert.Nil(t, os.Chtimes(keyFile, modTime, modTime))
+	}
+
+	t.Run("ReloadOnChange", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile := writeTestFile(t, dir, "client.crt", nil)
+		keyFile := writeTestFile(t, dir, "client.key", nil)
+		rotate(t, certFile, keyFile, newTestCertificate(t, "first-client", ca), time.Now().Add(-time.Hour))
+
+		client, err := NewBuilder().
+			SetClientCertificate(certFile, keyFile).
+			SetClientCertificateReloadInterval(time.Nanosecond).
+			SetRootCAs(newTestCertPool(ca)).
+			DisableKeepAlives(true).
+			Build()
+		assert.Nil(t, err)
+
+		response, err := client.Get(server.URL)
+		assert.Nil(t, err)
+		assert.EqualValues(t, "first-client", response.String())
+
+		rotate(t, certFile, keyFile, newTestCertificate(t, "second-client", ca), time.Now())
+
+		response, err = client.Get(server.URL)
+		assert.Nil(t, err)
+		assert.EqualValues(t, "second-client", response.String())
+	})
+
+	t.Run("KeepLastValidCertificate", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile := writeTestFile(t, dir, "client.crt", nil)
+		keyFile := writeTestFile(t, dir, "client.key", nil)
+		rotate(t, certFile, keyFile, newTestCertificate(t, "first-client", ca), time.Now().Add(-time.Hour))
+
+		reloader, err := newCertificateReloader(certFile, keyFile, time.Nanosecond)
+		assert.Nil(t, err)
+
+		// Only the certificate was rotated so far, the key does not match.
+		writeTestFile(t, "", certFile, newTestCertificate(t, "second-client", ca).certPEM)
+
+		certificate, err := reloader.GetClientCertificate(nil)
+		assert.Nil(t, err)
+		assert.NotNil(t, certificate)
+		leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+		assert.Nil(t, err)
+		assert.EqualValues(t, "first-client", leaf.Subject.CommonName)
+	})
+
+	t.Run("RequiresFiles", func(t *testing.T) {
+		_, err := NewBuilder().
+			SetClientCertificateReloadInterval(time.Minute).
+			Build()
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "client certificate reload requires the certificate and key files", err.Error())
+	})
+
+	t.Run("FilesAndPEM", func(t *testing.T) {
+		cert := newTestCertificate(t, "client", ca)
+		_, err := NewBuilder().
+			SetClientCertificate("client.crt", "client.key").
+			SetClientCertificatePEM(cert.certPEM, cert.keyPEM).
+			SetClientCertificateReloadInterval(time.Minute).
+			Build()
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "client certificate must be set either from files or from PEM bytes, not both", err.Error())
+	})
+}
@@ -1,27 +1,46 @@
 package gohttp
 
 import (
+	"crypto/tls"
 	"net/http"
+	"net/url"
 	"sync"
 
 	"github.com/getmiranda/go-httpclient/core"
+	"golang.org/x/time/rate"
 )
 
 type httpClient struct {
-	builder *clientBuilder
+	builder     *clientBuilder
+	tlsConfig   *tls.Config
+	proxy       func(*http.Request) (*url.URL, error)
+	dialContext dialContextFunc
+	endpoints   *endpointPool
+	health      *healthChecker
+	discovery   *serviceDiscovery
+	cache       *responseCache
+	coalescer   *requestCoalescer
+	bulkhead    *bulkhead
+
+	rateLimiter     *rate.Limiter
+	adaptiveLimiter *adaptiveRateLimiter
 
 	client     *http.Client
 	clientOnce sync.Once
+	closeOnce  sync.Once
 }
 
 // Client is the interface used to interact with the HTTP client.
+//
+// Every verb accepts options, such as WithHeader, WithQuery or WithRetry,
+// to tune a single request without building a new client.
 type Client interface {
 	// Get issues a GET HTTP verb to the specified URL.
 	//
 	// In Restful, GET is used for "reading" or retrieving a resource.
 	// Client should expect a response status code of 200(OK) if resource exists,
 	// 404(Not Found) if it doesn't, or 400(Bad Request).
-	Get(url string, headers ...http.Header) (*core.Response, error)
+	Get(url string, opts ...RequestOption) (*core.Response, error)
 	// Post issues a POST HTTP verb to the specified URL.
 	//
 	// In Restful, POST is used for "creating" a resource.
@@ -29,7 +48,7 @@ type Client interface {
 	// 404(Not Found), or 409(Conflict) if resource already exist.
 	//
 	// Body could be any of the form: string, []byte, struct & map.
-	Post(url string, body interface{}, headers ...http.Header) (*core.Response, error)
+	Post(url string, body interface{}, opts ...RequestOption) (*core.Response, error)
 	// Put issues a PUT HTTP verb to the specified URL.
 	//
 	// In Restful, PUT is used for "updating" a resource.
@@ -37,7 +56,7 @@ type Client interface {
 	// or 400(Bad Request). 200(OK) could be also 204(No Content)
 	//
 	// Body could be any of the form: string, []byte, struct & map.
-	Put(url string, body interface{}, headers ...http.Header) (*core.Response, error)
+	Put(url string, body interface{}, opts ...RequestOption) (*core.Response, error)
 	// Patch issues a PATCH HTTP verb to the specified URL
 	//
 	// In Restful, PATCH is used for "partially updating" a resource.
@@ -45,28 +64,57 @@ type Client interface {
 	// or 400(Bad Request). 200(OK) could be also 204(No Content)
 	//
 	// Body could be any of the form: string, []byte, struct & map.
-	Patch(url string, body interface{}, headers ...http.Header) (*core.Response, error)
+	Patch(url string, body interface{}, opts ...RequestOption) (*core.Response, error)
 	// Delete issues a DELETE HTTP verb to the specified URL
 	//
 	// In Restful, DELETE is used to "delete" a resource.
 	// Client should expect a response status code of of 200(OK), 404(Not Found),
 	// or 400(Bad Request).
-	Delete(url string, headers ...http.Header) (*core.Response, error)
+	Delete(url string, opts ...RequestOption) (*core.Response, error)
 	// Head issues a HEAD HTTP verb to the specified URL
 	//
 	// In Restful, HEAD is used to "read" a resource headers only.
 	// Client should expect a response status code of 200(OK) if resource exists,
 	// 404(Not Found) if it doesn't, or 400(Bad Request).
-	Head(url string, headers ...http.Header) (*core.Response, error)
+	Head(url string, opts ...RequestOption) (*core.Response, error)
 	// Options issues a OPTIONS HTTP verb to the specified URL
 	//
 	// In Restful, OPTIONS is used to get information about the resource
 	// and supported HTTP verbs.
 	// Client should expect a response status code of 200(OK) if resource exists,
 	// 404(Not Found) if it doesn't, or 400(Bad Request).
-	Options(url string, headers ...http.Header) (*core.Response, error)
+	Options(url string, opts ...RequestOption) (*core.Response, error)
 	// Do issues a custom HTTP request to the specified URL.
-	Do(req *http.Request) (*core.Response, error)
+	//
+	// The headers, query parameters, basic authentication, context, timeout
+	// and retry options are applied to a copy of the request. The defaults
+	// of the client are only applied with WithClientDefaults.
+	Do(req *http.Request, opts ...RequestOption) (*core.Response, error)
+	// R returns a new request builder, to build a request step by step and
+	// send it through the client.
+	R() *RequestBuilder
+	// Endpoints returns the current status of the base urls set with
+	// SetBaseUrls, or nil if they were not set.
+	Endpoints() []EndpointStatus
+	// BulkheadStats returns the current state of the concurrency limits
+	// set with SetMaxInFlight and SetMaxInFlightPerHost, such as the number
+	// of requests waiting in the queue.
+	BulkheadStats() BulkheadStats
+	// SetRateLimit changes the rate of the default rate limiter, set with
+	// SetRateLimiter, while the client is in use. The host and route rate
+	// limiters are not changed. A burst of zero, such as the one of the
+	// unlimited default limiter, is raised to 1.
+	SetRateLimit(r rate.Limit)
+	// SetRateBurst changes the number of requests the default rate limiter
+	// allows at once, while the client is in use.
+	SetRateBurst(requests int)
+	// RateLimitTokens returns the number of requests the default rate
+	// limiter allows right now, or +Inf if it is unlimited.
+	RateLimitTokens() float64
+	// Close stops the background tasks of the client, such as the
+	// health checks or the service discovery refresh, and closes its
+	// idle connections.
+	Close() error
 }
 
 // Get issues a GET HTTP verb to the specified URL.
@@ -74,8 +122,8 @@ type Client interface {
 // In Restful, GET is used for "reading" or retrieving a resource.
 // Client should expect a response status code of 200(OK) if resource exists,
 // 404(Not Found) if it doesn't, or 400(Bad Request).
-func (c *httpClient) Get(url string, headers ...http.Header) (*core.Response, error) {
-	return c.do(&request{http.MethodGet, url, getHeaders(headers...), nil, nil})
+func (c *httpClient) Get(url string, opts ...RequestOption) (*core.Response, error) {
+	return c.doRequest(http.MethodGet, url, nil, opts...)
 }
 
 // Post issues a POST HTTP verb to the specified URL.
@@ -85,8 +133,8 @@ func (c *httpClient) Get(url string, headers ...http.Header) (*core.Response, er
 // 404(Not Found), or 409(Conflict) if resource already exist.
 //
 // Body could be any of the form: string, []byte, struct & map.
-func (c *httpClient) Post(url string, body interface{}, headers ...http.Header) (*core.Response, error) {
-	return c.do(&request{http.MethodPost, url, getHeaders(headers...), body, nil})
+func (c *httpClient) Post(url string, body interface{}, opts ...RequestOption) (*core.Response, error) {
+	return c.doRequest(http.MethodPost, url, body, opts...)
 }
 
 // Put issues a PUT HTTP verb to the specified URL.
@@ -96,8 +144,8 @@ func (c *httpClient) Post(url string, body interface{}, headers ...http.Header)
 // or 400(Bad Request). 200(OK) could be also 204(No Content)
 //
 // Body could be any of the form: string, []byte, struct & map.
-func (c *httpClient) Put(url string, body interface{}, headers ...http.Header) (*core.Response, error) {
-	return c.do(&request{http.MethodPut, url, getHeaders(headers...), body, nil})
+func (c *httpClient) Put(url string, body interface{}, opts ...RequestOption) (*core.Response, error) {
+	return c.doRequest(http.MethodPut, url, body, opts...)
 }
 
 // Patch issues a PATCH HTTP verb to the specified URL
@@ -107,8 +155,8 @@ func (c *httpClient) Put(url string, body interface{}, headers ...http.Header) (
 // or 400(Bad Request). 200(OK) could be also 204(No Content)
 //
 // Body could be any of the form: string, []byte, struct & map.
-func (c *httpClient) Patch(url string, body interface{}, headers ...http.Header) (*core.Response, error) {
-	return c.do(&request{http.MethodPatch, url, getHeaders(headers...), body, nil})
+func (c *httpClient) Patch(url string, body interface{}, opts ...RequestOption) (*core.Response, error) {
+	return c.doRequest(http.MethodPatch, url, body, opts...)
 }
 
 // Delete issues a DELETE HTTP verb to the specified URL
@@ -116,8 +164,8 @@ func (c *httpClient) Patch(url string, body interface{}, headers ...http.Header)
 // In Restful, DELETE is used to "delete" a resource.
 // Client should expect a response status code of of 200(OK), 404(Not Found),
 // or 400(Bad Request).
-func (c *httpClient) Delete(url string, headers ...http.Header) (*core.Response, error) {
-	return c.do(&request{http.MethodDelete, url, getHeaders(headers...), nil, nil})
+func (c *httpClient) Delete(url string, opts ...RequestOption) (*core.Response, error) {
+	return c.doRequest(http.MethodDelete, url, nil, opts...)
 }
 
 // Head issues a HEAD HTTP verb to the specified URL
@@ -125,8 +173,8 @@ func (c *httpClient) Delete(url string, headers ...http.Header) (*core.Response,
 // In Restful, HEAD is used to "read" a resource headers only.
 // Client should expect a response status code of 200(OK) if resource exists,
 // 404(Not Found) if it doesn't, or 400(Bad Request).
-func (c *httpClient) Head(url string, headers ...http.Header) (*core.Response, error) {
-	return c.do(&request{http.MethodHead, url, getHeaders(headers...), nil, nil})
+func (c *httpClient) Head(url string, opts ...RequestOption) (*core.Response, error) {
+	return c.doRequest(http.MethodHead, url, nil, opts...)
 }
 
 // Options issues a OPTIONS HTTP verb to the specified URL
@@ -135,11 +183,48 @@ func (c *httpClient) Head(url string, headers ...http.Header) (*core.Response, e
 // and supported HTTP verbs.
 // Client should expect a response status code of 200(OK) if resource exists,
 // 404(Not Found) if it doesn't, or 400(Bad Request).
-func (c *httpClient) Options(url string, headers ...http.Header) (*core.Response, error) {
-	return c.do(&request{http.MethodOptions, url, getHeaders(headers...), nil, nil})
+func (c *httpClient) Options(url string, opts ...RequestOption) (*core.Response, error) {
+	return c.doRequest(http.MethodOptions, url, nil, opts...)
 }
 
 // Do issues a custom HTTP request to the specified URL.
-func (c *httpClient) Do(req *http.Request) (*core.Response, error) {
-	return c.do(&request{req: req})
+//
+// The headers, query parameters, basic authentication, context, timeout
+// and retry options are applied to a copy of the request. The defaults
+// of the client are only applied with WithClientDefaults.
+func (c *httpClient) Do(req *http.Request, opts ...RequestOption) (*core.Response, error) {
+	request := &request{req: req}
+	if err := request.apply(opts...); err != nil {
+		return nil, err
+	}
+	if err := c.bufferBody(request); err != nil {
+		return nil, err
+	}
+	return c.do(request)
+}
+
+func (c *httpClient) doRequest(method, url string, body interface{}, opts ...RequestOption) (*core.Response, error) {
+	request, err := newRequest(method, url, body, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(request)
+}
+
+// Close stops the background tasks of the client, such as the
+// health checks or the service discovery refresh, and closes its
+// idle connections.
+func (c *httpClient) Close() error {
+	c.closeOnce.Do(func() {
+		if c.health != nil {
+			c.health.stop()
+		}
+		if c.discovery != nil {
+			c.discovery.stop()
+		}
+		if client, ok := c.getHttpClient().(interface{ CloseIdleConnections() }); ok {
+			client.CloseIdleConnections()
+		}
+	})
+	return nil
 }
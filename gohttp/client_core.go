@@ -6,8 +6,8 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"io"
-	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -15,7 +15,6 @@ import (
 	"github.com/getmiranda/go-httpclient/core"
 	"github.com/getmiranda/go-httpclient/gohttp_testing"
 	"github.com/getmiranda/go-httpclient/gomime"
-	"golang.org/x/time/rate"
 )
 
 const (
@@ -25,24 +24,116 @@ const (
 )
 
 type request struct {
-	method  string
-	url     string
-	headers http.Header
-	body    interface{}
-	req     *http.Request
+	method      string
+	url         string
+	headers     http.Header
+	body        interface{}
+	query       url.Values
+	pathParams  map[string]string
+	basicAuth   *basicAuth
+	ctx         context.Context
+	timeout     time.Duration
+	retry       *retryPolicy
+	hedging     *hedgingPolicy
+	result      interface{}
+	errorResult interface{}
+	req         *http.Request
+	useDefaults bool
 }
 
 func (c *httpClient) do(request *request) (*core.Response, error) {
-	req, err := c.getRequest(request)
+	if request.timeout > 0 {
+		ctx, cancel := context.WithTimeout(request.getContext(), request.timeout)
+		defer cancel()
+		timeoutRequest := *request
+		timeoutRequest.ctx = ctx
+		request = &timeoutRequest
+	}
+
+	var response *core.Response
+	var err error
+	if request.retry != nil {
+		response, err = c.doWithRetry(request)
+	} else {
+		response, err = c.doOnce(request)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if err := decodeResponse(request, response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
+
+func (c *httpClient) doOnce(request *request) (*core.Response, error) {
+	if c.discovery != nil && request.req == nil {
+		if service, path, ok := getServiceName(request.url); ok {
+			pool, err := c.discovery.getPool(request.getContext(), service)
+			if err != nil {
+				return nil, err
+			}
+			serviceRequest := *request
+			serviceRequest.url = path
+			return c.doBalanced(&serviceRequest, pool)
+		}
+	}
+	if c.endpoints != nil && request.usesBaseUrl() {
+		return c.doBalanced(request, c.endpoints)
+	}
+
+	req, err := c.getRequest(request, c.builder.baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	return c.execute(request, req)
+}
+
+// execute sends the request through the request coalescing, the response
+// cache and the hedging, when enabled.
+func (c *httpClient) execute(request *request, req *http.Request) (*core.Response, error) {
+	hedging := c.getHedgingPolicy(request)
+	send := func(req *http.Request) (*core.Response, error) {
+		return c.sendHedged(req, hedging)
+	}
+	if c.cache != nil {
+		sendHedged := send
+		send = func(req *http.Request) (*core.Response, error) {
+			return c.cache.do(req, sendHedged)
+		}
+	}
+	if c.coalescer != nil {
+		return c.coalescer.do(req, send)
+	}
+	return send(req)
+}
+
+func (c *httpClient) send(req *http.Request) (*core.Response, error) {
+	if c.bulkhead != nil {
+		release, err := c.bulkhead.acquire(req.Context(), req.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	if err := c.waitRateLimit(req); err != nil {
+		return nil, err
+	}
 
-	ctx := context.Background()
-	if err := c.getRateLimit().Wait(ctx); err != nil { // This is a blocking call. Honors the rate limit
+	response, err := c.roundTrip(req)
+	if err != nil {
 		return nil, err
 	}
+	if c.adaptiveLimiter != nil {
+		c.adaptiveLimiter.update(req.URL.Host, response.StatusCode, response.Header)
+	}
+	return response, nil
+}
 
+// roundTrip sends the request and reads the response body, without going
+// through the concurrency and rate limits of the client.
+func (c *httpClient) roundTrip(req *http.Request) (*core.Response, error) {
 	response, err := c.getHttpClient().Do(req)
 	if err != nil {
 		return nil, err
@@ -61,9 +152,9 @@ func (c *httpClient) do(request *request) (*core.Response, error) {
 	return finalResponse, nil
 }
 
-func (c *httpClient) getRequest(request *request) (*http.Request, error) {
+func (c *httpClient) getRequest(request *request, baseUrl string) (*http.Request, error) {
 	if request.req != nil {
-		return request.req, nil
+		return c.getCustomRequest(request, baseUrl)
 	}
 
 	fullHeaders := c.getRequestHeaders(request.headers)
@@ -73,17 +164,106 @@ func (c *httpClient) getRequest(request *request) (*http.Request, error) {
 		return nil, err
 	}
 
-	url := c.builder.baseUrl + request.url
+	path, err := request.getPath()
+	if err != nil {
+		return nil, err
+	}
+	url, err := joinUrl(baseUrl, path)
+	if err != nil {
+		return nil, err
+	}
+	url, err = mergeQuery(url, c.builder.queryParams, request.query)
+	if err != nil {
+		return nil, err
+	}
 
-	req, err := http.NewRequest(request.method, url, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(request.getContext(), request.method, url, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, err
 	}
 	req.Header = fullHeaders
+	if request.basicAuth != nil {
+		req.SetBasicAuth(request.basicAuth.username, request.basicAuth.password)
+	}
 
 	return req, nil
 }
 
+// getCustomRequest returns a copy of the request given to Do with the
+// options of the request applied. The body is reset on every call when
+// possible, so the request can be retried.
+//
+// The default headers, user agent, base url and default query parameters
+// of the client are only applied with WithClientDefaults.
+func (c *httpClient) getCustomRequest(request *request, baseUrl string) (*http.Request, error) {
+	req := request.req.Clone(request.getContext())
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+
+	rawUrl := req.URL.String()
+	var defaultQuery url.Values
+	if request.useDefaults {
+		joined, err := joinUrl(baseUrl, rawUrl)
+		if err != nil {
+			return nil, err
+		}
+		rawUrl, defaultQuery = joined, c.builder.queryParams
+		c.setDefaultHeaders(req.Header)
+	}
+	fullUrl, err := mergeQuery(rawUrl, defaultQuery, request.query)
+	if err != nil {
+		return nil, err
+	}
+	if fullUrl != req.URL.String() {
+		if req.URL, err = url.Parse(fullUrl); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, values := range request.headers {
+		if len(values) > 0 {
+			req.Header.Set(key, values[0])
+		}
+	}
+	if request.basicAuth != nil {
+		req.SetBasicAuth(request.basicAuth.username, request.basicAuth.password)
+	}
+	return req, nil
+}
+
+// bufferBody reads the body of the request given to Do when it cannot be
+// reset and the request may be sent more than once, by the retries, the
+// failover between base urls or the hedged attempts, so every attempt sends
+// the whole body. The request of the caller is not modified.
+func (c *httpClient) bufferBody(request *request) error {
+	req := request.req
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+	if request.retry == nil && c.getHedgingPolicy(request) == nil && (c.endpoints == nil || !request.usesBaseUrl()) {
+		return nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	buffered := req.WithContext(req.Context())
+	buffered.Body = io.NopCloser(bytes.NewReader(body))
+	buffered.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	request.req = buffered
+	return nil
+}
+
 func (c *httpClient) getHttpClient() core.HttpClient {
 	if gohttp_testing.MockupServer.IsEnabled() {
 		return gohttp_testing.MockupServer.GetMockedClient()
@@ -98,10 +278,10 @@ func (c *httpClient) getHttpClient() core.HttpClient {
 			Transport: &http.Transport{
 				MaxIdleConnsPerHost:   c.getMaxIdleConnections(),
 				ResponseHeaderTimeout: c.getResponseTimeout(),
-				DialContext: (&net.Dialer{
-					Timeout: c.getConnectionTimeout(),
-				}).DialContext,
-				DisableKeepAlives: c.builder.disableKeepAlives,
+				DialContext:           c.dialContext,
+				DisableKeepAlives:     c.builder.disableKeepAlives,
+				TLSClientConfig:       c.tlsConfig,
+				Proxy:                 c.proxy,
 			},
 		}
 	})
@@ -155,10 +335,3 @@ func (c *httpClient) getConnectionTimeout() time.Duration {
 	}
 	return defaultConnectionTimeout
 }
-
-func (c *httpClient) getRateLimit() *rate.Limiter {
-	if c.builder.rateLimiter != nil {
-		return c.builder.rateLimiter
-	}
-	return rate.NewLimiter(rate.Inf, 0)
-}
@@ -0,0 +1,125 @@
+package gohttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/getmiranda/go-httpclient/core"
+)
+
+// defaultCoalescingKeyHeaders are the request headers always part of the
+// coalescing key, so requests on behalf of different users are not merged.
+var defaultCoalescingKeyHeaders = []string{"Authorization", "Cookie"}
+
+// coalescedCall is an in-flight request shared by identical requests.
+type coalescedCall struct {
+	done     chan struct{}
+	response *core.Response
+	err      error
+}
+
+// requestCoalescer collapses concurrent identical requests into a single
+// call, keyed by their method, url and the values of the default and the
+// given headers.
+//
+// Only idempotent requests without a body are coalesced. Every caller gets
+// its own copy of the response.
+type requestCoalescer struct {
+	headers []string
+
+	mutex sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+func newRequestCoalescer(headers []string) *requestCoalescer {
+	var canonical []string
+	seen := make(map[string]bool)
+	for _, header := range append(append([]string(nil), defaultCoalescingKeyHeaders...), headers...) {
+		header = http.CanonicalHeaderKey(header)
+		if !seen[header] {
+			seen[header] = true
+			canonical = append(canonical, header)
+		}
+	}
+	return &requestCoalescer{
+		headers: canonical,
+		calls:   make(map[string]*coalescedCall),
+	}
+}
+
+func (rc *requestCoalescer) getKey(req *http.Request) string {
+	var key strings.Builder
+	key.WriteString(req.Method)
+	key.WriteString(" ")
+	key.WriteString(req.URL.String())
+	for _, header := range rc.headers {
+		key.WriteString("\n")
+		key.WriteString(header)
+		key.WriteString(": ")
+		key.WriteString(strings.Join(req.Header.Values(header), ", "))
+	}
+	return key.String()
+}
+
+// do sends the request, or waits for the identical request in flight.
+//
+// When the shared call fails because the context of the caller that sent
+// it is done, the waiting callers send the request again.
+func (rc *requestCoalescer) do(req *http.Request, send func(*http.Request) (*core.Response, error)) (*core.Response, error) {
+	if !isIdempotent(req.Method) || (req.Body != nil && req.Body != http.NoBody) {
+		return send(req)
+	}
+
+	key := rc.getKey(req)
+	ctx := req.Context()
+	for {
+		rc.mutex.Lock()
+		if call, ok := rc.calls[key]; ok {
+			rc.mutex.Unlock()
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if isContextError(call.err) && ctx.Err() == nil {
+				continue
+			}
+			return copyResponse(call.response), call.err
+		}
+		call := &coalescedCall{done: make(chan struct{})}
+		rc.calls[key] = call
+		rc.mutex.Unlock()
+
+		call.response, call.err = send(req)
+
+		rc.mutex.Lock()
+		delete(rc.calls, key)
+		rc.mutex.Unlock()
+		close(call.done)
+
+		return copyResponse(call.response), call.err
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// copyResponse returns a copy of the response with its own headers and
+// body bytes.
+func copyResponse(response *core.Response) *core.Response {
+	if response == nil {
+		return nil
+	}
+	copied := *response
+	copied.BodyBytes = append([]byte(nil), response.BodyBytes...)
+	if response.Response != nil {
+		httpResponse := *response.Response
+		httpResponse.Header = response.Header.Clone()
+		copied.Response = &httpResponse
+	}
+	return &copied
+}
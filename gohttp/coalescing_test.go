@@ -0,0 +1,131 @@
+package gohttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getmiranda/go-httpclient/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestCoalescing(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		<-release
+		w.Write([]byte("reference " + r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	client, err := NewBuilder().
+		SetBaseUrl(server.URL).
+		EnableRequestCoalescing(true).
+		SetCoalescingKeyHeaders("x-tenant").
+		Build()
+	assert.Nil(t, err)
+
+	getAll := func(n int, opts func(i int) []RequestOption) []*core.Response {
+		atomic.StoreInt32(&hits, 0)
+		release = make(chan struct{})
+		responses := make([]*core.Response, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				response, err := client.Get("/reference", opts(i)...)
+				assert.Nil(t, err)
+				responses[i] = response
+			}(i)
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		return responses
+	}
+
+	t.Run("Coalesced", func(t *testing.T) {
+		responses := getAll(10, func(i int) []RequestOption { return nil })
+
+		assert.EqualValues(t, 1, atomic.LoadInt32(&hits))
+		responses[0].BodyBytes[0] = 'X'
+		responses[0].Header.Set("X-Modified", "true")
+		for _, response := range responses[1:] {
+			assert.EqualValues(t, "reference ", response.String())
+			assert.EqualValues(t, "", response.Header.Get("X-Modified"))
+		}
+	})
+
+	t.Run("DefaultKeyHeaders", func(t *testing.T) {
+		responses := getAll(10, func(i int) []RequestOption {
+			return []RequestOption{WithHeader("Authorization", []string{"a", "b"}[i%2])}
+		})
+
+		assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+		for i, response := range responses {
+			assert.EqualValues(t, "reference "+[]string{"a", "b"}[i%2], response.String())
+		}
+
+		getAll(10, func(i int) []RequestOption {
+			return []RequestOption{WithHeader("Cookie", []string{"session=a", "session=b"}[i%2])}
+		})
+		assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+	})
+
+	t.Run("KeyHeaders", func(t *testing.T) {
+		getAll(10, func(i int) []RequestOption {
+			return []RequestOption{WithHeader("X-Tenant", []string{"a", "b"}[i%2])}
+		})
+
+		assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+	})
+
+	t.Run("NotIdempotent", func(t *testing.T) {
+		atomic.StoreInt32(&hits, 0)
+		release = make(chan struct{})
+		close(release)
+		for i := 0; i < 2; i++ {
+			_, err := client.Post("/reference", nil)
+			assert.Nil(t, err)
+		}
+		assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+	})
+}
+
+func TestRequestCoalescingCancelledCaller(t *testing.T) {
+	coalescer := newRequestCoalescer(nil)
+	var calls int32
+	started := make(chan struct{})
+	send := func(req *http.Request) (*core.Response, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return &core.Response{BodyBytes: []byte("ok"), Response: &http.Response{StatusCode: http.StatusOK}}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.example.com/reference", nil)
+	second, _ := http.NewRequest(http.MethodGet, "https://api.example.com/reference", nil)
+
+	go coalescer.do(first, send)
+	<-started
+	result := make(chan *core.Response)
+	go func() {
+		response, err := coalescer.do(second, send)
+		assert.Nil(t, err)
+		result <- response
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	assert.EqualValues(t, "ok", (<-result).String())
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,80 @@
+package gohttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+type dialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// getDialContext returns the function used by the transport to create
+// the network connections.
+func (c *httpClient) getDialContext() (dialContextFunc, error) {
+	if c.builder.unixSocket != "" && c.builder.dialContext != nil {
+		return nil, errors.New("dialer must be set either from a unix socket or from a dial function, not both")
+	}
+
+	dialer := &net.Dialer{
+		Timeout: c.getConnectionTimeout(),
+	}
+
+	if c.builder.unixSocket != "" {
+		path := c.builder.unixSocket
+		return func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		}, nil
+	}
+
+	dial := dialer.DialContext
+	if c.builder.dialContext != nil {
+		dial = withDialTimeout(c.builder.dialContext, c.getConnectionTimeout())
+	}
+
+	if resolver := c.getHostResolver(); resolver != nil {
+		dialer := newAddressDialer(dial, resolver)
+		dialer.addressTimeout = c.builder.dialAddressTimeout
+		dialer.fallbackDelay = c.builder.dialFallbackDelay
+		dialer.failedAddressTTL = c.builder.failedAddressTTL
+		return withDialTimeout(dialer.DialContext, c.getConnectionTimeout()), nil
+	}
+	return dial, nil
+}
+
+// getHostResolver returns the resolver used to look up the hosts before
+// dialing, or nil to let the dialer resolve them.
+func (c *httpClient) getHostResolver() HostResolver {
+	resolver := c.builder.hostResolver
+	if c.builder.dnsCacheTTL > 0 {
+		if resolver == nil {
+			resolver = net.DefaultResolver
+		}
+		cache := newDNSCache(resolver, c.builder.dnsCacheTTL, c.builder.dnsCacheMaxEntries)
+		cache.staleWhileRevalidate = c.builder.dnsStaleWhileRevalidate
+		cache.staleIfError = c.builder.dnsStaleIfError
+		return cache
+	}
+	if resolver == nil && c.hasAddressDialSettings() {
+		return net.DefaultResolver
+	}
+	return resolver
+}
+
+func (c *httpClient) hasAddressDialSettings() bool {
+	return c.builder.dialAddressTimeout > 0 ||
+		c.builder.dialFallbackDelay > 0 ||
+		c.builder.failedAddressTTL > 0
+}
+
+// withDialTimeout applies the given timeout to the context passed to dial.
+func withDialTimeout(dial dialContextFunc, timeout time.Duration) dialContextFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return dial(ctx, network, addr)
+	}
+}
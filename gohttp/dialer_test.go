@@ -0,0 +1,72 @@
+package gohttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "test.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host + r.URL.Path))
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	client, err := NewBuilder().SetUnixSocket(socket).Build()
+	assert.Nil(t, err)
+
+	response, err := client.Get("http://docker/containers/json")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "docker/containers/json", response.String())
+}
+
+func TestDialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("dialed"))
+	}))
+	defer server.Close()
+
+	t.Run("CustomDialContext", func(t *testing.T) {
+		var dialedAddr string
+		var hasDeadline bool
+		client, err := NewBuilder().
+			SetConnectionTimeout(time.Second).
+			SetDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+				dialedAddr = addr
+				_, hasDeadline = ctx.Deadline()
+				return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+			}).
+			Build()
+		assert.Nil(t, err)
+
+		response, err := client.Get("http://sidecar:8080/")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "dialed", response.String())
+		assert.EqualValues(t, "sidecar:8080", dialedAddr)
+		assert.True(t, hasDeadline)
+	})
+
+	t.Run("UnixSocketAndDialContext", func(t *testing.T) {
+		_, err := NewBuilder().
+			SetUnixSocket("/var/run/docker.sock").
+			SetDialContext((&net.Dialer{}).DialContext).
+			Build()
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "dialer must be set either from a unix socket or from a dial function, not both", err.Error())
+	})
+}
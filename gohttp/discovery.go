@@ -0,0 +1,237 @@
+package gohttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ServiceScheme is the url scheme of the requests whose endpoints are
+	// resolved by the Resolver of the client, e.g. "svc://billing/v1/invoices".
+	ServiceScheme = "svc"
+
+	defaultDiscoveryTimeout = time.Second * 10
+)
+
+// Resolver is the interface that turns a logical service name into the
+// base urls of its endpoints.
+type Resolver interface {
+	// Resolve returns the base urls of the given service. It must be safe
+	// for concurrent use.
+	Resolve(ctx context.Context, service string) ([]string, error)
+}
+
+type staticResolver struct {
+	services map[string][]string
+}
+
+// NewStaticResolver returns a Resolver for a fixed list of base urls by service.
+func NewStaticResolver(services map[string][]string) Resolver {
+	return &staticResolver{services: services}
+}
+
+func (r *staticResolver) Resolve(ctx context.Context, service string) ([]string, error) {
+	baseUrls, ok := r.services[service]
+	if !ok {
+		return nil, fmt.Errorf("unknown service %s", service)
+	}
+	return baseUrls, nil
+}
+
+type fileResolver struct {
+	path string
+}
+
+// NewFileResolver returns a Resolver reading the base urls by service from
+// a JSON file, e.g. {"billing": ["http://localhost:8081"]}. The file is
+// read on every resolution, so it can be changed while the client runs.
+func NewFileResolver(path string) Resolver {
+	return &fileResolver{path: path}
+}
+
+func (r *fileResolver) Resolve(ctx context.Context, service string) ([]string, error) {
+	content, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+	var services map[string][]string
+	if err := json.Unmarshal(content, &services); err != nil {
+		return nil, fmt.Errorf("invalid services file %s: %w", r.path, err)
+	}
+	return NewStaticResolver(services).Resolve(ctx, service)
+}
+
+type srvResolver struct {
+	scheme    string
+	domain    string
+	lookupSRV func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// NewSRVResolver returns a Resolver looking up the DNS SRV records of the
+// services, i.e. "_<service>._tcp.<domain>". The base urls are built with
+// the given scheme from the targets with the lowest priority.
+func NewSRVResolver(scheme, domain string) Resolver {
+	return &srvResolver{
+		scheme:    scheme,
+		domain:    domain,
+		lookupSRV: net.DefaultResolver.LookupSRV,
+	}
+}
+
+func (r *srvResolver) Resolve(ctx context.Context, service string) ([]string, error) {
+	_, records, err := r.lookupSRV(ctx, service, "tcp", r.domain)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseUrls []string
+	for _, record := range records {
+		// The records are sorted by priority, only the lowest one is used.
+		if record.Priority != records[0].Priority {
+			break
+		}
+		host := net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+		baseUrls = append(baseUrls, r.scheme+"://"+host)
+	}
+	return baseUrls, nil
+}
+
+// getServiceName splits a service url into the service name and the
+// remaining path, e.g. "svc://billing/v1/invoices" into "billing" and
+// "/v1/invoices".
+func getServiceName(rawUrl string) (string, string, bool) {
+	rest := strings.TrimPrefix(rawUrl, ServiceScheme+"://")
+	if rest == rawUrl {
+		return "", "", false
+	}
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		return rest[:i], rest[i:], true
+	}
+	return rest, "", true
+}
+
+// serviceDiscovery keeps a pool of endpoints for each service, refreshing
+// them from the resolver every refreshInterval.
+type serviceDiscovery struct {
+	resolver        Resolver
+	refreshInterval time.Duration
+	newPool         func(baseUrls []string) *endpointPool
+
+	mutex sync.Mutex
+	pools map[string]*endpointPool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newServiceDiscovery(resolver Resolver, refreshInterval time.Duration, newPool func(baseUrls []string) *endpointPool) *serviceDiscovery {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &serviceDiscovery{
+		resolver:        resolver,
+		refreshInterval: refreshInterval,
+		newPool:         newPool,
+		pools:           make(map[string]*endpointPool),
+		ctx:             ctx,
+		cancel:          cancel,
+		done:            make(chan struct{}),
+	}
+}
+
+// getPool returns the pool of the given service, resolving its endpoints
+// the first time it is requested.
+func (d *serviceDiscovery) getPool(ctx context.Context, service string) (*endpointPool, error) {
+	d.mutex.Lock()
+	pool, ok := d.pools[service]
+	d.mutex.Unlock()
+	if ok {
+		return pool, nil
+	}
+
+	baseUrls, err := d.resolve(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if pool, ok := d.pools[service]; ok {
+		return pool, nil
+	}
+	pool = d.newPool(baseUrls)
+	d.pools[service] = pool
+	return pool, nil
+}
+
+func (d *serviceDiscovery) resolve(ctx context.Context, service string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultDiscoveryTimeout)
+	defer cancel()
+
+	baseUrls, err := d.resolver.Resolve(ctx, service)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving service %s: %w", service, err)
+	}
+	if len(baseUrls) == 0 {
+		return nil, fmt.Errorf("no endpoints found for service %s", service)
+	}
+	for _, baseUrl := range baseUrls {
+		if _, err := parseBaseUrl(baseUrl); err != nil {
+			return nil, fmt.Errorf("error resolving service %s: %w", service, err)
+		}
+	}
+	return baseUrls, nil
+}
+
+func (d *serviceDiscovery) start() {
+	if d.refreshInterval <= 0 {
+		close(d.done)
+		return
+	}
+	go d.run()
+}
+
+// stop stops the periodic refresh, waiting for a running one to finish.
+func (d *serviceDiscovery) stop() {
+	d.cancel()
+	<-d.done
+}
+
+func (d *serviceDiscovery) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			d.refresh()
+		}
+	}
+}
+
+// refresh resolves again the endpoints of every known service. A service
+// keeps its current endpoints if the resolution fails.
+func (d *serviceDiscovery) refresh() {
+	d.mutex.Lock()
+	pools := make(map[string]*endpointPool, len(d.pools))
+	for service, pool := range d.pools {
+		pools[service] = pool
+	}
+	d.mutex.Unlock()
+
+	for service, pool := range pools {
+		if baseUrls, err := d.resolve(d.ctx, service); err == nil {
+			pool.setBaseUrls(baseUrls)
+		}
+	}
+}
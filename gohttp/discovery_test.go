@@ -0,0 +1,115 @@
+package gohttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetServiceName(t *testing.T) {
+	service, path, ok := getServiceName("svc://billing/v1/invoices?page=2")
+	assert.True(t, ok)
+	assert.EqualValues(t, "billing", service)
+	assert.EqualValues(t, "/v1/invoices?page=2", path)
+
+	service, path, ok = getServiceName("svc://billing")
+	assert.True(t, ok)
+	assert.EqualValues(t, "billing", service)
+	assert.EqualValues(t, "", path)
+
+	_, _, ok = getServiceName("https://billing/v1/invoices")
+	assert.False(t, ok)
+}
+
+func TestServiceDiscovery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("billing " + r.URL.String()))
+	}))
+	defer server.Close()
+
+	t.Run("StaticResolver", func(t *testing.T) {
+		client, err := NewBuilder().
+			SetResolver(NewStaticResolver(map[string][]string{"billing": {server.URL}}), 0).
+			Build()
+		assert.Nil(t, err)
+		defer client.Close()
+
+		response, err := client.Get("svc://billing/v1/invoices?page=2")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "billing /v1/invoices?page=2", response.String())
+	})
+
+	t.Run("UnknownService", func(t *testing.T) {
+		client, err := NewBuilder().
+			SetResolver(NewStaticResolver(map[string][]string{}), 0).
+			Build()
+		assert.Nil(t, err)
+		defer client.Close()
+
+		response, err := client.Get("svc://billing/v1/invoices")
+
+		assert.Nil(t, response)
+		assert.EqualValues(t, "error resolving service billing: unknown service billing", err.Error())
+	})
+
+	t.Run("FileResolver", func(t *testing.T) {
+		file := writeTestFile(t, t.TempDir(), "services.json", []byte(`{"billing": ["`+server.URL+`"]}`))
+		client, err := NewBuilder().SetResolver(NewFileResolver(file), 0).Build()
+		assert.Nil(t, err)
+		defer client.Close()
+
+		response, err := client.Get("svc://billing/v1/invoices")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "billing /v1/invoices", response.String())
+	})
+
+	t.Run("Refresh", func(t *testing.T) {
+		services := map[string][]string{"billing": {"http://a", "http://b"}}
+		discovery := newServiceDiscovery(NewStaticResolver(services), time.Minute, func(baseUrls []string) *endpointPool {
+			return newEndpointPool(baseUrls, nil)
+		})
+
+		pool, err := discovery.getPool(context.Background(), "billing")
+		assert.Nil(t, err)
+		b := pool.getEndpoints()[1]
+
+		services["billing"] = []string{"http://b", "http://c"}
+		discovery.refresh()
+
+		endpoints := pool.getEndpoints()
+		assert.EqualValues(t, 2, len(endpoints))
+		assert.Same(t, b, endpoints[0])
+		assert.EqualValues(t, "http://c", endpoints[1].BaseUrl())
+
+		// A failed resolution keeps the current endpoints:
+		delete(services, "billing")
+		discovery.refresh()
+		assert.EqualValues(t, endpoints, pool.getEndpoints())
+	})
+}
+
+func TestSRVResolver(t *testing.T) {
+	resolver := NewSRVResolver("https", "example.com").(*srvResolver)
+	resolver.lookupSRV = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		assert.EqualValues(t, "billing", service)
+		assert.EqualValues(t, "tcp", proto)
+		assert.EqualValues(t, "example.com", name)
+		return "_billing._tcp.example.com.", []*net.SRV{
+			{Target: "billing-1.example.com.", Port: 8443, Priority: 10},
+			{Target: "billing-2.example.com.", Port: 8443, Priority: 10},
+			{Target: "billing-dr.example.com.", Port: 443, Priority: 20},
+		}, nil
+	}
+
+	baseUrls, err := resolver.Resolve(context.Background(), "billing")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"https://billing-1.example.com:8443", "https://billing-2.example.com:8443"}, baseUrls)
+}
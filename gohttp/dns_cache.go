@@ -0,0 +1,156 @@
+package gohttp
+
+import (
+	"container/list"
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+const defaultDNSRefreshTimeout = time.Second * 10
+
+// HostResolver is the interface that wraps the LookupIPAddr method.
+//
+// *net.Resolver implements it, so net.DefaultResolver can be used.
+type HostResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+type dnsCacheEntry struct {
+	host       string
+	addrs      []net.IPAddr
+	expires    time.Time
+	next       int
+	refreshing bool
+}
+
+// dnsCache is an in-process cache of the addresses resolved for each host.
+//
+// Entries are fresh for ttl. Once expired, an entry is still returned for
+// staleWhileRevalidate while it is refreshed in the background, and for
+// staleIfError when the resolver fails. The least recently used entry is
+// evicted when the cache holds more than maxEntries hosts.
+type dnsCache struct {
+	resolver             HostResolver
+	ttl                  time.Duration
+	maxEntries           int
+	staleWhileRevalidate time.Duration
+	staleIfError         time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	now     func() time.Time
+}
+
+func newDNSCache(resolver HostResolver, ttl time.Duration, maxEntries int) *dnsCache {
+	return &dnsCache{
+		resolver:   resolver,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// LookupIPAddr returns the addresses of the given host, rotating their
+// order on every call so connections are spread across all of them.
+func (d *dnsCache) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	d.mutex.Lock()
+	entry := d.get(host)
+	if entry != nil {
+		now := d.now()
+		if now.Before(entry.expires) {
+			defer d.mutex.Unlock()
+			return entry.rotate(), nil
+		}
+		if now.Before(entry.expires.Add(d.staleWhileRevalidate)) {
+			defer d.mutex.Unlock()
+			if !entry.refreshing {
+				entry.refreshing = true
+				go d.refresh(host)
+			}
+			return entry.rotate(), nil
+		}
+	}
+	d.mutex.Unlock()
+
+	addrs, err := d.resolver.LookupIPAddr(ctx, host)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err != nil {
+		if entry != nil && d.now().Before(entry.expires.Add(d.staleIfError)) {
+			return entry.rotate(), nil
+		}
+		return nil, err
+	}
+	return d.set(host, addrs).rotate(), nil
+}
+
+func (d *dnsCache) refresh(host string) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultDNSRefreshTimeout)
+	defer cancel()
+
+	addrs, err := d.resolver.LookupIPAddr(ctx, host)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err != nil {
+		if entry := d.get(host); entry != nil {
+			entry.refreshing = false
+		}
+		return
+	}
+	d.set(host, addrs)
+}
+
+// get returns the entry of the given host, if any, marking it as the most
+// recently used. It must be called with the mutex held.
+func (d *dnsCache) get(host string) *dnsCacheEntry {
+	element, ok := d.entries[host]
+	if !ok {
+		return nil
+	}
+	d.lru.MoveToFront(element)
+	return element.Value.(*dnsCacheEntry)
+}
+
+// set stores the addresses of the given host, evicting the least recently
+// used entries if needed. It must be called with the mutex held.
+func (d *dnsCache) set(host string, addrs []net.IPAddr) *dnsCacheEntry {
+	entry := &dnsCacheEntry{
+		host:    host,
+		addrs:   addrs,
+		expires: d.now().Add(d.ttl),
+	}
+	if element, ok := d.entries[host]; ok {
+		element.Value = entry
+		d.lru.MoveToFront(element)
+		return entry
+	}
+
+	d.entries[host] = d.lru.PushFront(entry)
+	for d.maxEntries > 0 && d.lru.Len() > d.maxEntries {
+		oldest := d.lru.Back()
+		d.lru.Remove(oldest)
+		delete(d.entries, oldest.Value.(*dnsCacheEntry).host)
+	}
+	return entry
+}
+
+// rotate returns a copy of the addresses starting from the next one in a
+// round-robin fashion.
+func (e *dnsCacheEntry) rotate() []net.IPAddr {
+	if len(e.addrs) == 0 {
+		return nil
+	}
+	start := e.next % len(e.addrs)
+	e.next++
+
+	addrs := make([]net.IPAddr, 0, len(e.addrs))
+	addrs = append(addrs, e.addrs[start:]...)
+	return append(addrs, e.addrs[:start]...)
+}
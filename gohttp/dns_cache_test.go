@@ -0,0 +1,171 @@
+package gohttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type resolverMock struct {
+	mutex   sync.Mutex
+	addrs   map[string][]net.IPAddr
+	err     error
+	lookups int
+}
+
+func newResolverMock(host string, ips ...string) *resolverMock {
+	r := &resolverMock{addrs: make(map[string][]net.IPAddr)}
+	for _, ip := range ips {
+		r.addrs[host] = append(r.addrs[host], net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return r
+}
+
+func (r *resolverMock) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.lookups++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.addrs[host], nil
+}
+
+func (r *resolverMock) getLookups() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.lookups
+}
+
+func TestDNSCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CacheUntilExpiration", func(t *testing.T) {
+		resolver := newResolverMock("api.example.com", "10.0.0.1")
+		cache := newDNSCache(resolver, time.Minute, 0)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		for i := 0; i < 3; i++ {
+			addrs, err := cache.LookupIPAddr(ctx, "api.example.com")
+			assert.Nil(t, err)
+			assert.EqualValues(t, "10.0.0.1", addrs[0].String())
+		}
+		assert.EqualValues(t, 1, resolver.getLookups())
+
+		now = now.Add(time.Minute)
+		_, err := cache.LookupIPAddr(ctx, "api.example.com")
+		assert.Nil(t, err)
+		assert.EqualValues(t, 2, resolver.getLookups())
+	})
+
+	t.Run("RoundRobin", func(t *testing.T) {
+		resolver := newResolverMock("api.example.com", "10.0.0.1", "10.0.0.2", "10.0.0.3")
+		cache := newDNSCache(resolver, time.Minute, 0)
+
+		var first []string
+		for i := 0; i < 4; i++ {
+			addrs, err := cache.LookupIPAddr(ctx, "api.example.com")
+			assert.Nil(t, err)
+			assert.EqualValues(t, 3, len(addrs))
+			first = append(first, addrs[0].String())
+		}
+		assert.EqualValues(t, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.1"}, first)
+	})
+
+	t.Run("StaleWhileRevalidate", func(t *testing.T) {
+		resolver := newResolverMock("api.example.com", "10.0.0.1")
+		cache := newDNSCache(resolver, time.Minute, 0)
+		cache.staleWhileRevalidate = time.Minute
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		_, err := cache.LookupIPAddr(ctx, "api.example.com")
+		assert.Nil(t, err)
+
+		resolver.mutex.Lock()
+		resolver.addrs["api.example.com"] = []net.IPAddr{{IP: net.ParseIP("10.0.0.2")}}
+		resolver.mutex.Unlock()
+		now = now.Add(90 * time.Second)
+
+		addrs, err := cache.LookupIPAddr(ctx, "api.example.com")
+		assert.Nil(t, err)
+		assert.EqualValues(t, "10.0.0.1", addrs[0].String())
+
+		assert.Eventually(t, func() bool {
+			addrs, err := cache.LookupIPAddr(ctx, "api.example.com")
+			return err == nil && addrs[0].String() == "10.0.0.2"
+		}, time.Second, time.Millisecond)
+		assert.EqualValues(t, 2, resolver.getLookups())
+	})
+
+	t.Run("StaleIfError", func(t *testing.T) {
+		resolver := newResolverMock("api.example.com", "10.0.0.1")
+		cache := newDNSCache(resolver, time.Minute, 0)
+		cache.staleIfError = time.Hour
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		_, err := cache.LookupIPAddr(ctx, "api.example.com")
+		assert.Nil(t, err)
+
+		resolver.err = errors.New("resolver unavailable")
+		now = now.Add(30 * time.Minute)
+		addrs, err := cache.LookupIPAddr(ctx, "api.example.com")
+		assert.Nil(t, err)
+		assert.EqualValues(t, "10.0.0.1", addrs[0].String())
+
+		now = now.Add(time.Hour)
+		addrs, err = cache.LookupIPAddr(ctx, "api.example.com")
+		assert.Nil(t, addrs)
+		assert.EqualValues(t, "resolver unavailable", err.Error())
+	})
+
+	t.Run("MaxEntries", func(t *testing.T) {
+		resolver := newResolverMock("a.example.com", "10.0.0.1")
+		resolver.addrs["b.example.com"] = resolver.addrs["a.example.com"]
+		resolver.addrs["c.example.com"] = resolver.addrs["a.example.com"]
+		cache := newDNSCache(resolver, time.Minute, 2)
+
+		for _, host := range []string{"a.example.com", "b.example.com", "a.example.com", "c.example.com"} {
+			_, err := cache.LookupIPAddr(ctx, host)
+			assert.Nil(t, err)
+		}
+
+		assert.EqualValues(t, 2, len(cache.entries))
+		assert.Contains(t, cache.entries, "a.example.com")
+		assert.Contains(t, cache.entries, "c.example.com")
+	})
+}
+
+func TestDialWithResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+	port := server.URL[strings.LastIndex(server.URL, ":")+1:]
+
+	resolver := newResolverMock("api.example.com", "127.0.0.1")
+	client, err := NewBuilder().
+		SetHostResolver(resolver).
+		SetDNSCache(time.Minute, 100).
+		DisableKeepAlives(true).
+		Build()
+	assert.Nil(t, err)
+
+	for i := 0; i < 3; i++ {
+		response, err := client.Get("http://api.example.com:" + port)
+		assert.Nil(t, err)
+		assert.EqualValues(t, "api.example.com:"+port, response.String())
+	}
+	assert.EqualValues(t, 1, resolver.getLookups())
+}
@@ -6,13 +6,6 @@ import (
 	"github.com/getmiranda/go-httpclient/gomime"
 )
 
-func getHeaders(headers ...http.Header) http.Header {
-	if len(headers) > 0 {
-		return headers[0]
-	}
-	return http.Header{}
-}
-
 func (c *httpClient) getRequestHeaders(requestHeaders http.Header) http.Header {
 	result := make(http.Header)
 	// Add default headers to the request
@@ -37,3 +30,16 @@ func (c *httpClient) getRequestHeaders(requestHeaders http.Header) http.Header {
 	}
 	return result
 }
+
+// setDefaultHeaders adds the default headers and user agent of the client
+// to the given headers, unless they are already set.
+func (c *httpClient) setDefaultHeaders(headers http.Header) {
+	for k, v := range c.builder.headers {
+		if len(v) > 0 && headers.Get(k) == "" {
+			headers.Set(k, v[0])
+		}
+	}
+	if c.builder.userAgent != "" && headers.Get(gomime.HeaderUserAgent) == "" {
+		headers.Set(gomime.HeaderUserAgent, c.builder.userAgent)
+	}
+}
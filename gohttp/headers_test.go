@@ -46,24 +46,3 @@ func TestGetRequestHeaders(t *testing.T) {
 		assert.EqualValues(t, "cool-agent", finalheaders.Get("User-Agent"))
 	})
 }
-
-func TestGetHeaders(t *testing.T) {
-	t.Run("SetCustomHeader", func(t *testing.T) {
-		header1 := make(http.Header)
-		header1.Set("Content-Type", "application/json")
-		header2 := make(http.Header)
-		header2.Set("X-Request-Id", "ABC-123")
-
-		header := getHeaders(header1, header2)
-
-		assert.EqualValues(t, 1, len(header), "Should have 1 header")
-		assert.EqualValues(t, "application/json", header.Get("Content-Type"))
-	})
-
-	t.Run("SetDefaultHeader", func(t *testing.T) {
-		header := getHeaders()
-
-		assert.NotNil(t, header)
-		assert.Equal(t, http.Header{}, header)
-	})
-}
@@ -0,0 +1,171 @@
+package gohttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHealthyThreshold   = 2
+	defaultUnhealthyThreshold = 3
+)
+
+// EndpointStatus is the current status of one of the base urls of the client.
+type EndpointStatus struct {
+	BaseUrl string
+	// Healthy is false when the endpoint failed the last health checks.
+	Healthy bool
+	// Ejected is true while the endpoint is ejected for failing requests.
+	Ejected bool
+	// InFlight is the number of requests being sent to the endpoint.
+	InFlight int
+	// LastCheck is the time of the last health check, if any.
+	LastCheck time.Time
+	// LastCheckError is the error of the last health check, if it failed.
+	LastCheckError error
+}
+
+// healthChecker periodically probes the endpoints, marking them down after
+// unhealthyThreshold consecutive failed probes and back up after
+// healthyThreshold consecutive successful ones.
+type healthChecker struct {
+	client             *httpClient
+	pool               *endpointPool
+	path               string
+	interval           time.Duration
+	healthyThreshold   int
+	unhealthyThreshold int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newHealthChecker(client *httpClient, pool *endpointPool, path string, interval time.Duration) *healthChecker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &healthChecker{
+		client:             client,
+		pool:               pool,
+		path:               path,
+		interval:           interval,
+		healthyThreshold:   defaultHealthyThreshold,
+		unhealthyThreshold: defaultUnhealthyThreshold,
+		ctx:                ctx,
+		cancel:             cancel,
+		done:               make(chan struct{}),
+	}
+}
+
+func (h *healthChecker) start() {
+	go h.run()
+}
+
+// stop stops the health checks, waiting for the running probes to finish.
+func (h *healthChecker) stop() {
+	h.cancel()
+	<-h.done
+}
+
+func (h *healthChecker) run() {
+	defer close(h.done)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.checkAll()
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *healthChecker) checkAll() {
+	var wg sync.WaitGroup
+	for _, endpoint := range h.pool.getEndpoints() {
+		wg.Add(1)
+		go func(endpoint *Endpoint) {
+			defer wg.Done()
+			h.record(endpoint, h.check(endpoint))
+		}(endpoint)
+	}
+	wg.Wait()
+}
+
+// check probes the endpoint using the client itself, so the probes go
+// through the same transport, proxy and TLS settings as the requests. The
+// probes bypass the concurrency and rate limits, which must not mark the
+// endpoints down.
+func (h *healthChecker) check(endpoint *Endpoint) error {
+	url, err := joinUrl(endpoint.BaseUrl(), h.path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(h.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header = h.client.getRequestHeaders(nil)
+
+	response, err := h.client.roundTrip(req)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("health check failed with status code %d", response.StatusCode)
+	}
+	return nil
+}
+
+func (h *healthChecker) record(endpoint *Endpoint, err error) {
+	if h.ctx.Err() != nil {
+		return
+	}
+
+	endpoint.mutex.Lock()
+	defer endpoint.mutex.Unlock()
+
+	endpoint.lastCheck = time.Now()
+	endpoint.lastCheckErr = err
+	if err != nil {
+		endpoint.checkSuccesses = 0
+		endpoint.checkFailures++
+		if endpoint.checkFailures >= h.unhealthyThreshold {
+			endpoint.unhealthy = true
+		}
+		return
+	}
+	endpoint.checkFailures = 0
+	endpoint.checkSuccesses++
+	if endpoint.checkSuccesses >= h.healthyThreshold {
+		endpoint.unhealthy = false
+	}
+}
+
+// Endpoints returns the current status of the base urls of the client.
+func (c *httpClient) Endpoints() []EndpointStatus {
+	if c.endpoints == nil {
+		return nil
+	}
+
+	now := time.Now()
+	var statuses []EndpointStatus
+	for _, endpoint := range c.endpoints.getEndpoints() {
+		endpoint.mutex.Lock()
+		statuses = append(statuses, EndpointStatus{
+			BaseUrl:        endpoint.baseUrl,
+			Healthy:        !endpoint.unhealthy,
+			Ejected:        now.Before(endpoint.ejectedUntil),
+			InFlight:       endpoint.InFlight(),
+			LastCheck:      endpoint.lastCheck,
+			LastCheckError: endpoint.lastCheckErr,
+		})
+		endpoint.mutex.Unlock()
+	}
+	return statuses
+}
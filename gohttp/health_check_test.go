@@ -0,0 +1,133 @@
+package gohttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestHealthCheck(t *testing.T) {
+	var down, probes int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			atomic.AddInt32(&probes, 1)
+			if atomic.LoadInt32(&down) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		w.Write([]byte("flaky"))
+	}))
+	defer flaky.Close()
+	stable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("stable"))
+	}))
+	defer stable.Close()
+
+	client, err := NewBuilder().
+		SetBaseUrls([]string{flaky.URL, stable.URL}).
+		SetHealthCheck("/health", 5*time.Millisecond).
+		SetHealthCheckThresholds(1, 2).
+		Build()
+	assert.Nil(t, err)
+
+	isHealthy := func(baseUrl string) bool {
+		for _, status := range client.Endpoints() {
+			if status.BaseUrl == baseUrl {
+				return status.Healthy
+			}
+		}
+		return false
+	}
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&probes) > 0
+	}, time.Second, time.Millisecond)
+	assert.True(t, isHealthy(flaky.URL))
+	assert.True(t, isHealthy(stable.URL))
+
+	atomic.StoreInt32(&down, 1)
+	assert.Eventually(t, func() bool {
+		return !isHealthy(flaky.URL)
+	}, time.Second, time.Millisecond)
+	for i := 0; i < 3; i++ {
+		response, err := client.Get("/")
+		assert.Nil(t, err)
+		assert.EqualValues(t, "stable", response.String())
+	}
+
+	atomic.StoreInt32(&down, 0)
+	assert.Eventually(t, func() bool {
+		return isHealthy(flaky.URL)
+	}, time.Second, time.Millisecond)
+
+	assert.Nil(t, client.Close())
+	stopped := atomic.LoadInt32(&probes)
+	time.Sleep(20 * time.Millisecond)
+	assert.EqualValues(t, stopped, atomic.LoadInt32(&probes))
+}
+
+func TestHealthCheckJoinsUrl(t *testing.T) {
+	var probes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(&probes, 1)
+	}))
+	defer server.Close()
+
+	client, err := NewBuilder().
+		SetBaseUrls([]string{server.URL + "/api/"}).
+		SetHealthCheck("/health", 5*time.Millisecond).
+		SetHealthCheckThresholds(1, 1).
+		Build()
+	assert.Nil(t, err)
+	defer client.Close()
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&probes) >= 3
+	}, time.Second, time.Millisecond)
+	assert.True(t, client.Endpoints()[0].Healthy)
+}
+
+func TestHealthCheckBypassesLimits(t *testing.T) {
+	var probes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&probes, 1)
+	}))
+	defer server.Close()
+
+	client, err := NewBuilder().
+		SetBaseUrls([]string{server.URL}).
+		SetHealthCheck("/health", 5*time.Millisecond).
+		SetHealthCheckThresholds(1, 1).
+		SetRateLimiter(rate.Every(time.Hour), 1).
+		EnableRateLimitFailFast(true).
+		SetMaxInFlight(1).
+		SetBulkheadQueue(0, 0).
+		Build()
+	assert.Nil(t, err)
+	defer client.Close()
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&probes) >= 3
+	}, time.Second, time.Millisecond)
+	assert.True(t, client.Endpoints()[0].Healthy)
+}
+
+func TestHealthCheckRequiresBaseUrls(t *testing.T) {
+	_, err := NewBuilder().
+		SetBaseUrl("https://api.example.com").
+		SetHealthCheck("/health", time.Second).
+		Build()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "health check requires the base urls to be set with SetBaseUrls", err.Error())
+}
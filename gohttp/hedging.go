@@ -0,0 +1,99 @@
+package gohttp
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/getmiranda/go-httpclient/core"
+)
+
+type hedgingPolicy struct {
+	delay     time.Duration
+	maxHedges int
+}
+
+type hedgeResult struct {
+	response *core.Response
+	err      error
+}
+
+// WithHedging sends up to maxHedges extra attempts of the request, one
+// every delay while no attempt has answered, replacing the hedging of the
+// client. Zero maxHedges disables the hedging of the client.
+//
+// Only idempotent requests are hedged.
+func WithHedging(delay time.Duration, maxHedges int) RequestOption {
+	return func(r *request) error {
+		r.hedging = &hedgingPolicy{delay: delay, maxHedges: maxHedges}
+		return nil
+	}
+}
+
+func (c *httpClient) getHedgingPolicy(request *request) *hedgingPolicy {
+	if request.hedging != nil {
+		return request.hedging
+	}
+	if c.builder.hedgingMaxHedges > 0 {
+		return &hedgingPolicy{delay: c.builder.hedgingDelay, maxHedges: c.builder.hedgingMaxHedges}
+	}
+	return nil
+}
+
+// sendHedged sends the request and, while it has not answered, sends
+// another attempt every delay, up to maxHedges extra attempts. The first
+// response is returned and the other attempts are cancelled. Every attempt
+// waits for the rate limiter.
+//
+// An error is only returned once every attempt sent has failed.
+func (c *httpClient) sendHedged(req *http.Request, policy *hedgingPolicy) (*core.Response, error) {
+	if policy == nil || policy.maxHedges <= 0 || policy.delay <= 0 || !isIdempotent(req.Method) {
+		return c.send(req)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	results := make(chan hedgeResult, policy.maxHedges+1)
+	attempts, pending := 0, 0
+	launch := func() error {
+		attempt := req.Clone(ctx)
+		if req.GetBody != nil && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			attempt.Body = body
+		}
+		attempts++
+		pending++
+		go func() {
+			response, err := c.send(attempt)
+			results <- hedgeResult{response: response, err: err}
+		}()
+		return nil
+	}
+
+	if err := launch(); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(policy.delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if attempts <= policy.maxHedges {
+				if err := launch(); err != nil {
+					return nil, err
+				}
+				timer.Reset(policy.delay)
+			}
+		case result := <-results:
+			pending--
+			if result.err == nil || pending == 0 {
+				return result.response, result.err
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package gohttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHedging(t *testing.T) {
+	var hits, cancelled int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit := atomic.AddInt32(&hits, 1)
+		if hit == 1 {
+			select {
+			case <-r.Context().Done():
+				atomic.AddInt32(&cancelled, 1)
+				return
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+		fmt.Fprintf(w, "attempt %d", hit)
+	}))
+	defer server.Close()
+
+	reset := func() {
+		atomic.StoreInt32(&hits, 0)
+		atomic.StoreInt32(&cancelled, 0)
+	}
+
+	t.Run("SecondAttemptWins", func(t *testing.T) {
+		reset()
+		client, err := NewBuilder().SetBaseUrl(server.URL).SetHedging(20*time.Millisecond, 2).Build()
+		assert.Nil(t, err)
+
+		start := time.Now()
+		response, err := client.Get("/")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "attempt 2", response.String())
+		assert.Less(t, int64(time.Since(start)), int64(150*time.Millisecond))
+		assert.Eventually(t, func() bool {
+			return atomic.LoadInt32(&cancelled) == 1
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("RequestOption", func(t *testing.T) {
+		reset()
+		client, err := NewBuilder().SetBaseUrl(server.URL).SetHedging(20*time.Millisecond, 2).Build()
+		assert.Nil(t, err)
+
+		response, err := client.Get("/", WithHedging(0, 0))
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "attempt 1", response.String())
+		assert.EqualValues(t, 1, atomic.LoadInt32(&hits))
+	})
+
+	t.Run("NotIdempotent", func(t *testing.T) {
+		reset()
+		client, err := NewBuilder().SetBaseUrl(server.URL).Build()
+		assert.Nil(t, err)
+
+		response, err := client.Post("/", "body", WithHedging(20*time.Millisecond, 2))
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "attempt 1", response.String())
+	})
+
+	t.Run("RateLimited", func(t *testing.T) {
+		reset()
+		client, err := NewBuilder().
+			SetBaseUrl(server.URL).
+			SetRateLimiter(0.1, 1).
+			SetHedging(20*time.Millisecond, 2).
+			Build()
+		assert.Nil(t, err)
+
+		response, err := client.Get("/")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "attempt 1", response.String())
+		assert.EqualValues(t, 1, atomic.LoadInt32(&hits))
+	})
+}
@@ -0,0 +1,195 @@
+package gohttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/getmiranda/go-httpclient/gomime"
+)
+
+// RequestOption configures a single request made by the client.
+type RequestOption func(r *request) error
+
+type basicAuth struct {
+	username string
+	password string
+}
+
+// WithHeader sets the given header for the request, replacing the common
+// header with the same name.
+func WithHeader(key, value string) RequestOption {
+	return func(r *request) error {
+		r.getHeaders().Set(key, value)
+		return nil
+	}
+}
+
+// WithHeaders sets the given headers for the request, replacing the common
+// headers with the same name.
+func WithHeaders(headers http.Header) RequestOption {
+	return func(r *request) error {
+		for key, values := range headers {
+			if len(values) > 0 {
+				r.getHeaders().Set(key, values[0])
+			}
+		}
+		return nil
+	}
+}
+
+// WithQuery adds the given query parameters to the request url. The query
+// could be url.Values, a map or a struct as described in EncodeQuery.
+//
+// Parameters replace the ones with the same name in the request url and
+// the default query parameters of the client.
+func WithQuery(query interface{}) RequestOption {
+	return func(r *request) error {
+		values, err := EncodeQuery(query)
+		if err != nil {
+			return err
+		}
+		if r.query == nil {
+			r.query = values
+			return nil
+		}
+		for key, value := range values {
+			r.query[key] = value
+		}
+		return nil
+	}
+}
+
+// WithPathParams expands the parameters of the request url, used as a
+// template as described in ExpandPath.
+func WithPathParams(params map[string]string) RequestOption {
+	return func(r *request) error {
+		if r.pathParams == nil {
+			r.pathParams = make(map[string]string, len(params))
+		}
+		for key, value := range params {
+			r.pathParams[key] = value
+		}
+		return nil
+	}
+}
+
+// WithContext sets the context of the request. Once the context is done,
+// the request is cancelled and no more retries are attempted.
+func WithContext(ctx context.Context) RequestOption {
+	return func(r *request) error {
+		if ctx == nil {
+			return errors.New("nil context")
+		}
+		r.ctx = ctx
+		return nil
+	}
+}
+
+// WithTimeout sets the maximum amount of time for the whole request,
+// including any retries and reading the response body.
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(r *request) error {
+		r.timeout = timeout
+		return nil
+	}
+}
+
+// WithRetry sends the request again, up to maxRetries times, when it fails
+// with a network error or with a 429, 502, 503 or 504 status code. The wait
+// before each retry starts at backoff and doubles after every attempt.
+func WithRetry(maxRetries int, backoff time.Duration) RequestOption {
+	return func(r *request) error {
+		r.retry = &retryPolicy{
+			maxRetries: maxRetries,
+			backoff:    backoff,
+		}
+		return nil
+	}
+}
+
+// WithBasicAuth sets the Authorization header of the request to use
+// HTTP Basic Authentication with the given username and password.
+func WithBasicAuth(username, password string) RequestOption {
+	return func(r *request) error {
+		r.basicAuth = &basicAuth{username: username, password: password}
+		return nil
+	}
+}
+
+// WithBearerToken sets the Authorization header of the request to use
+// the given bearer token.
+func WithBearerToken(token string) RequestOption {
+	return WithHeader(gomime.HeaderAuthorization, "Bearer "+token)
+}
+
+// WithClientDefaults applies the default headers, user agent, base url and
+// default query parameters of the client to the request given to Do, which
+// are not applied otherwise. The headers of the request are kept.
+func WithClientDefaults() RequestOption {
+	return func(r *request) error {
+		r.useDefaults = true
+		return nil
+	}
+}
+
+// newRequest creates a request applying the given options.
+func newRequest(method, url string, body interface{}, opts ...RequestOption) (*request, error) {
+	r := &request{
+		method: method,
+		url:    url,
+		body:   body,
+	}
+	if err := r.apply(opts...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *request) apply(opts ...RequestOption) error {
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *request) getHeaders() http.Header {
+	if r.headers == nil {
+		r.headers = make(http.Header)
+	}
+	return r.headers
+}
+
+// getContext returns the context set with WithContext, otherwise the one
+// of the request given to Do.
+func (r *request) getContext() context.Context {
+	if r.ctx != nil {
+		return r.ctx
+	}
+	if r.req != nil {
+		return r.req.Context()
+	}
+	return context.Background()
+}
+
+// usesBaseUrl checks whether the request is sent to the base urls of the
+// client, which is only the case for relative urls.
+func (r *request) usesBaseUrl() bool {
+	if r.req != nil {
+		return r.useDefaults && !r.req.URL.IsAbs()
+	}
+	u, err := url.Parse(r.url)
+	return err != nil || !u.IsAbs()
+}
+
+// getPath returns the request url with its path parameters expanded.
+func (r *request) getPath() (string, error) {
+	if len(r.pathParams) == 0 {
+		return r.url, nil
+	}
+	return ExpandPath(r.url, r.pathParams)
+}
@@ -0,0 +1,103 @@
+package gohttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		w.Write([]byte(strings.Join([]string{
+			r.URL.RequestURI(),
+			r.Header.Get("X-Request-Id"),
+			username + ":" + password,
+		}, " ")))
+	}))
+	defer server.Close()
+
+	client, err := NewBuilder().SetBaseUrl(server.URL).Build()
+	assert.Nil(t, err)
+
+	t.Run("Verb", func(t *testing.T) {
+		response, err := client.Get("/users/{id}/repos",
+			WithPathParams(map[string]string{"id": "john doe"}),
+			WithQuery(map[string]int{"page": 2}),
+			WithHeader("X-Request-Id", "ABC-123"),
+			WithBasicAuth("john", "secret"),
+		)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "/users/john%20doe/repos?page=2 ABC-123 john:secret", response.String())
+	})
+
+	t.Run("Do", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/users?page=1", nil)
+		response, err := client.Do(req,
+			WithQuery(url.Values{"page": {"2"}}),
+			WithHeader("X-Request-Id", "ABC-123"),
+		)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "/users?page=2 ABC-123 :", response.String())
+		assert.EqualValues(t, "", req.Header.Get("X-Request-Id"))
+		assert.EqualValues(t, "page=1", req.URL.RawQuery)
+	})
+
+	t.Run("InvalidOption", func(t *testing.T) {
+		response, err := client.Get("/users", WithQuery("page=2"))
+
+		assert.Nil(t, response)
+		assert.EqualValues(t, "query must be url.Values, a map or a struct, got string", err.Error())
+	})
+}
+
+func TestWithTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewBuilder().Build()
+	assert.Nil(t, err)
+
+	t.Run("Timeout", func(t *testing.T) {
+		response, err := client.Get(server.URL, WithTimeout(10*time.Millisecond))
+
+		assert.Nil(t, response)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		response, err := client.Get(server.URL, WithContext(ctx))
+
+		assert.Nil(t, response)
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+
+	t.Run("DoContext", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+		start := time.Now()
+		response, err := client.Do(req, WithTimeout(time.Second), WithRetry(3, 10*time.Millisecond))
+
+		assert.Nil(t, response)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+	})
+}
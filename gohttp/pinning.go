@@ -0,0 +1,143 @@
+package gohttp
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+)
+
+const pinPrefixSha256 = "sha256/"
+
+// PinMismatchError is returned when none of the certificates presented by
+// the server matches the public key pins configured for the host.
+type PinMismatchError struct {
+	// Host is the server name the connection was made to.
+	Host string
+	// Pins are the pins of the certificates presented by the server.
+	Pins []string
+}
+
+func (e *PinMismatchError) Error() string {
+	return fmt.Sprintf("public key pin mismatch for host %s, got pins: %s", e.Host, strings.Join(e.Pins, ", "))
+}
+
+type publicKeyPinner struct {
+	pins       map[string]map[string]bool
+	reportOnly bool
+}
+
+func newPublicKeyPinner(pins, backupPins map[string][]string, reportOnly bool) (*publicKeyPinner, error) {
+	pinner := &publicKeyPinner{
+		pins:       make(map[string]map[string]bool),
+		reportOnly: reportOnly,
+	}
+	for _, hostPins := range []map[string][]string{pins, backupPins} {
+		for host, values := range hostPins {
+			if net.ParseIP(strings.Trim(host, "[]")) != nil {
+				return nil, fmt.Errorf("invalid public key pin host %q: IP addresses are not sent in the TLS server name, pin a hostname instead", host)
+			}
+			if pinner.pins[host] == nil {
+				pinner.pins[host] = make(map[string]bool)
+			}
+			for _, value := range values {
+				pin, err := parsePin(value)
+				if err != nil {
+					return nil, err
+				}
+				pinner.pins[host][pin] = true
+			}
+		}
+	}
+	return pinner, nil
+}
+
+// parsePin validates the given pin and returns it without the hash prefix.
+func parsePin(pin string) (string, error) {
+	pin = strings.TrimPrefix(pin, pinPrefixSha256)
+	hash, err := base64.StdEncoding.DecodeString(pin)
+	if err != nil || len(hash) != sha256.Size {
+		return "", fmt.Errorf("invalid public key pin %q: must be a base64 encoded SHA-256 hash", pin)
+	}
+	return pin, nil
+}
+
+// getPin returns the base64 encoded SHA-256 hash of the certificate
+// Subject Public Key Info.
+func getPin(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// getHostPins returns the pins for the given host, looking for a wildcard
+// entry of the parent domain if there is no exact match.
+func (p *publicKeyPinner) getHostPins(host string) map[string]bool {
+	return p.pins[p.getPinnedHost(host)]
+}
+
+// getPinnedHost returns the pinned host, exact or wildcard, matching the
+// given host, or an empty string if there is none.
+func (p *publicKeyPinner) getPinnedHost(host string) string {
+	host = strings.ToLower(host)
+	if _, ok := p.pins[host]; ok {
+		return host
+	}
+	if i := strings.Index(host, "."); i >= 0 {
+		if _, ok := p.pins["*"+host[i:]]; ok {
+			return "*" + host[i:]
+		}
+	}
+	return ""
+}
+
+// checkServerName checks that every pinned host matches the server name
+// overriding the hosts of the urls, as the pins are only looked up by the
+// server name of the connection and the other pins would never be checked.
+func (p *publicKeyPinner) checkServerName(serverName string) error {
+	pinned := p.getPinnedHost(serverName)
+	for host := range p.pins {
+		if host != pinned {
+			return fmt.Errorf("public key pins for host %q are never checked with the server name %q: pin the server name instead", host, serverName)
+		}
+	}
+	return nil
+}
+
+// VerifyConnection checks the presented certificates against the pins of
+// the host. It matches the signature of tls.Config.VerifyConnection.
+func (p *publicKeyPinner) VerifyConnection(state tls.ConnectionState) error {
+	hostPins := p.getHostPins(state.ServerName)
+	if hostPins == nil {
+		return nil
+	}
+
+	chains := state.VerifiedChains
+	if len(chains) == 0 {
+		chains = [][]*x509.Certificate{state.PeerCertificates}
+	}
+
+	var presented []string
+	for _, chain := range chains {
+		for _, cert := range chain {
+			pin := getPin(cert)
+			if hostPins[pin] {
+				return nil
+			}
+			presented = append(presented, pin)
+		}
+	}
+
+	err := &PinMismatchError{
+		Host: state.ServerName,
+		Pins: presented,
+	}
+	if p.reportOnly {
+		log.Printf("%s (report only)", err)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+package gohttp
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicKeyPinning(t *testing.T) {
+	ca := newTestCertificate(t, "test-ca", nil)
+	serverCert := newTestCertificate(t, "localhost", ca)
+	otherCert := newTestCertificate(t, "other", nil)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pinned"))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert.tlsCertificate(t)}}
+	server.StartTLS()
+	defer server.Close()
+	serverUrl := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	t.Run("PinMatches", func(t *testing.T) {
+		client, err := NewBuilder().
+			SetRootCAs(newTestCertPool(ca)).
+			SetPublicKeyPins("localhost", getPin(otherCert.cert), "sha256/"+getPin(serverCert.cert)).
+			Build()
+		assert.Nil(t, err)
+
+		response, err := client.Get(serverUrl)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "pinned", response.String())
+	})
+
+	t.Run("BackupPinMatches", func(t *testing.T) {
+		client, err := NewBuilder().
+			SetRootCAs(newTestCertPool(ca)).
+			SetPublicKeyPins("localhost", getPin(otherCert.cert)).
+			SetBackupPublicKeyPins("localhost", getPin(ca.cert)).
+			Build()
+		assert.Nil(t, err)
+
+		response, err := client.Get(serverUrl)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "pinned", response.String())
+	})
+
+	t.Run("PinMismatch", func(t *testing.T) {
+		client, err := NewBuilder().
+			SetRootCAs(newTestCertPool(ca)).
+			SetPublicKeyPins("localhost", getPin(otherCert.cert)).
+			Build()
+		assert.Nil(t, err)
+
+		response, err := client.Get(serverUrl)
+
+		assert.Nil(t, response)
+		var pinErr *PinMismatchError
+		assert.True(t, errors.As(err, &pinErr))
+		assert.EqualValues(t, "localhost", pinErr.Host)
+		assert.EqualValues(t, []string{getPin(serverCert.cert), getPin(ca.cert)}, pinErr.Pins)
+	})
+
+	t.Run("ReportOnly", func(t *testing.T) {
+		client, err := NewBuilder().
+			SetRootCAs(newTestCertPool(ca)).
+			SetPublicKeyPins("localhost", getPin(otherCert.cert)).
+			SetPublicKeyPinningReportOnly(true).
+			Build()
+		assert.Nil(t, err)
+
+		response, err := client.Get(serverUrl)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "pinned", response.String())
+	})
+
+	t.Run("InvalidPin", func(t *testing.T) {
+		_, err := NewBuilder().
+			SetPublicKeyPins("localhost", "not-a-pin").
+			Build()
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, `invalid public key pin "not-a-pin": must be a base64 encoded SHA-256 hash`, err.Error())
+	})
+
+	t.Run("ServerName", func(t *testing.T) {
+		_, err := NewBuilder().
+			SetServerName("localhost").
+			SetPublicKeyPins("api.example.com", getPin(otherCert.cert)).
+			Build()
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, `public key pins for host "api.example.com" are never checked with the server name "localhost": pin the server name instead`, err.Error())
+
+		client, err := NewBuilder().
+			SetRootCAs(newTestCertPool(ca)).
+			SetServerName("localhost").
+			SetPublicKeyPins("localhost", getPin(otherCert.cert)).
+			Build()
+		assert.Nil(t, err)
+
+		// The pins of the server name are checked for the IP address.
+		_, err = client.Get(server.URL)
+		var pinErr *PinMismatchError
+		assert.True(t, errors.As(err, &pinErr))
+	})
+
+	t.Run("IPHost", func(t *testing.T) {
+		for _, host := range []string{"127.0.0.1", "::1", "[::1]"} {
+			_, err := NewBuilder().
+				SetPublicKeyPins(host, getPin(otherCert.cert)).
+				Build()
+
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), "IP addresses are not sent in the TLS server name")
+		}
+
+		_, err := NewBuilder().
+			SetBackupPublicKeyPins("127.0.0.1", getPin(otherCert.cert)).
+			Build()
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGetHostPins(t *testing.T) {
+	pin := getPin(newTestCertificate(t, "test", nil).cert)
+	pinner, err := newPublicKeyPinner(map[string][]string{
+		"api.example.com": {pin},
+		"*.payments.com":  {pin},
+	}, nil, false)
+	assert.Nil(t, err)
+
+	assert.NotNil(t, pinner.getHostPins("api.example.com"))
+	assert.NotNil(t, pinner.getHostPins("API.Example.com"))
+	assert.NotNil(t, pinner.getHostPins("eu.payments.com"))
+	assert.Nil(t, pinner.getHostPins("payments.com"))
+	assert.Nil(t, pinner.getHostPins("www.example.com"))
+}
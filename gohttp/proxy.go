@@ -0,0 +1,159 @@
+package gohttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type noProxyEntry struct {
+	ip      net.IP
+	network *net.IPNet
+	domain  string
+	port    string
+}
+
+// proxyBypass matches the hosts that are reached without going through the proxy.
+type proxyBypass struct {
+	all     bool
+	entries []noProxyEntry
+}
+
+// getProxy returns the function used by the transport to select the proxy
+// for a given request, or nil if no proxy was configured in the builder.
+func (c *httpClient) getProxy() (func(*http.Request) (*url.URL, error), error) {
+	if c.builder.proxyUrl != "" && c.builder.proxyFromEnvironment {
+		return nil, errors.New("proxy must be set either from an url or from the environment, not both")
+	}
+
+	bypass, err := newProxyBypass(c.builder.noProxy)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.builder.proxyUrl != "" {
+		proxyUrl, err := parseProxyUrl(c.builder.proxyUrl)
+		if err != nil {
+			return nil, err
+		}
+		return func(req *http.Request) (*url.URL, error) {
+			if bypass.match(req.URL) {
+				return nil, nil
+			}
+			return proxyUrl, nil
+		}, nil
+	}
+
+	if c.builder.proxyFromEnvironment {
+		return func(req *http.Request) (*url.URL, error) {
+			if bypass.match(req.URL) {
+				return nil, nil
+			}
+			return http.ProxyFromEnvironment(req)
+		}, nil
+	}
+	return nil, nil
+}
+
+func parseProxyUrl(rawUrl string) (*url.URL, error) {
+	proxyUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url: %w", err)
+	}
+	switch proxyUrl.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy url %q: unsupported scheme %q", proxyUrl.Redacted(), proxyUrl.Scheme)
+	}
+	if proxyUrl.Host == "" {
+		return nil, fmt.Errorf("invalid proxy url %q: missing host", proxyUrl.Redacted())
+	}
+	return proxyUrl, nil
+}
+
+func newProxyBypass(hosts []string) (*proxyBypass, error) {
+	bypass := &proxyBypass{}
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" {
+			continue
+		}
+		if host == "*" {
+			bypass.all = true
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(host); err == nil {
+			bypass.entries = append(bypass.entries, noProxyEntry{network: network})
+			continue
+		}
+
+		var entry noProxyEntry
+		if h, port, err := net.SplitHostPort(host); err == nil {
+			host, entry.port = h, port
+		}
+		if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+			entry.ip = ip
+		} else {
+			entry.domain = strings.TrimPrefix(strings.TrimPrefix(host, "*"), ".")
+			if entry.domain == "" {
+				return nil, fmt.Errorf("invalid no proxy host %q", host)
+			}
+			// Only a leading "." restricts the entry to the subdomains.
+			if strings.HasPrefix(host, ".") || strings.HasPrefix(host, "*.") {
+				entry.domain = "." + entry.domain
+			}
+		}
+		bypass.entries = append(bypass.entries, entry)
+	}
+	return bypass, nil
+}
+
+// match checks whether the given url must bypass the proxy.
+func (b *proxyBypass) match(u *url.URL) bool {
+	if b.all {
+		return true
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = getDefaultPort(u.Scheme)
+	}
+	ip := net.ParseIP(host)
+
+	for _, entry := range b.entries {
+		if entry.port != "" && entry.port != port {
+			continue
+		}
+		switch {
+		case entry.network != nil:
+			if ip != nil && entry.network.Contains(ip) {
+				return true
+			}
+		case entry.ip != nil:
+			if ip != nil && entry.ip.Equal(ip) {
+				return true
+			}
+		case strings.HasPrefix(entry.domain, "."):
+			if strings.HasSuffix(host, entry.domain) {
+				return true
+			}
+		default:
+			if host == entry.domain || strings.HasSuffix(host, "."+entry.domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func getDefaultPort(scheme string) string {
+	if scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
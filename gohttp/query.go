@@ -0,0 +1,292 @@
+package gohttp
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type queryTagOptions struct {
+	omitEmpty bool
+	comma     bool
+	unix      bool
+	layout    string
+}
+
+// EncodeQuery encodes the given value as query parameters.
+//
+// The value could be url.Values, a map with string keys or a struct. The
+// struct fields are encoded using the name given in their "url" tag, or
+// the field name, and the following tag options:
+//
+//	Name   string    `url:"name,omitempty"` // skipped if empty
+//	Tags   []string  `url:"tags"`           // tags=a&tags=b
+//	Ids    []int     `url:"ids,comma"`      // ids=1,2
+//	Since  time.Time `url:"since"`          // RFC 3339, unless set with the "layout" tag
+//	Until  time.Time `url:"until,unix"`     // seconds since the Unix epoch
+//	Filter Filter    `url:"filter"`         // filter[field]=value
+//	Secret string    `url:"-"`              // always skipped
+//
+// Embedded structs without a name in their tag are flattened.
+func EncodeQuery(v interface{}) (url.Values, error) {
+	values := make(url.Values)
+	switch query := v.(type) {
+	case nil:
+		return values, nil
+	case url.Values:
+		for key, value := range query {
+			values[key] = append([]string(nil), value...)
+		}
+		return values, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if err := encodeMap(values, "", rv); err != nil {
+			return nil, err
+		}
+	case reflect.Struct:
+		if err := encodeStruct(values, "", rv); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("query must be url.Values, a map or a struct, got %T", v)
+	}
+	return values, nil
+}
+
+func getQueryKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "[" + name + "]"
+}
+
+func encodeMap(values url.Values, prefix string, rv reflect.Value) error {
+	if rv.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("query map keys must be strings, got %s", rv.Type().Key())
+	}
+
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, key := range keys {
+		if err := encodeQueryValue(values, getQueryKey(prefix, key.String()), rv.MapIndex(key), queryTagOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeStruct(values url.Values, prefix string, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseQueryTag(tag)
+		opts.layout = field.Tag.Get("layout")
+
+		fv := rv.Field(i)
+		if field.Anonymous && name == "" {
+			embedded := fv
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && embedded.Type() != timeType {
+				if err := encodeStruct(values, prefix, embedded); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if err := encodeQueryValue(values, getQueryKey(prefix, name), fv, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseQueryTag(tag string) (string, queryTagOptions) {
+	parts := strings.Split(tag, ",")
+	var opts queryTagOptions
+	for _, option := range parts[1:] {
+		switch option {
+		case "omitempty":
+			opts.omitEmpty = true
+		case "comma":
+			opts.comma = true
+		case "unix":
+			opts.unix = true
+		}
+	}
+	return parts[0], opts
+}
+
+func encodeQueryValue(values url.Values, key string, rv reflect.Value, opts queryTagOptions) error {
+	if opts.omitEmpty && (!rv.IsValid() || rv.IsZero() || isEmptyCollection(rv)) {
+		return nil
+	}
+
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			values.Add(key, "")
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Type() != timeType && !rv.Type().Implements(textMarshalerType) {
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			if rv.Type().Elem().Kind() != reflect.Uint8 {
+				return encodeQuerySlice(values, key, rv, opts)
+			}
+		case reflect.Struct:
+			return encodeStruct(values, key, rv)
+		case reflect.Map:
+			return encodeMap(values, key, rv)
+		}
+	}
+
+	value, err := formatQueryValue(rv, opts)
+	if err != nil {
+		return fmt.Errorf("error encoding query parameter %s: %w", key, err)
+	}
+	values.Add(key, value)
+	return nil
+}
+
+func encodeQuerySlice(values url.Values, key string, rv reflect.Value, opts queryTagOptions) error {
+	items := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		item := rv.Index(i)
+		for item.Kind() == reflect.Ptr || item.Kind() == reflect.Interface {
+			if item.IsNil() {
+				break
+			}
+			item = item.Elem()
+		}
+		value, err := formatQueryValue(item, opts)
+		if err != nil {
+			return fmt.Errorf("error encoding query parameter %s: %w", key, err)
+		}
+		items = append(items, value)
+	}
+
+	if opts.comma {
+		values.Add(key, strings.Join(items, ","))
+		return nil
+	}
+	for _, item := range items {
+		values.Add(key, item)
+	}
+	return nil
+}
+
+func formatQueryValue(rv reflect.Value, opts queryTagOptions) (string, error) {
+	if (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && rv.IsNil() {
+		return "", nil
+	}
+
+	if rv.Type() == timeType {
+		t := rv.Interface().(time.Time)
+		switch {
+		case opts.unix:
+			return strconv.FormatInt(t.Unix(), 10), nil
+		case opts.layout != "":
+			return t.Format(opts.layout), nil
+		}
+		return t.Format(time.RFC3339), nil
+	}
+
+	if rv.Type().Implements(textMarshalerType) {
+		text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return string(rv.Bytes()), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", rv.Type())
+}
+
+func isEmptyCollection(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return rv.Len() == 0
+	}
+	return false
+}
+
+// mergeQuery adds the default query parameters of the client and the query
+// parameters of the request to the given url. The parameters of the request
+// replace the ones with the same name in the url, which replace the default
+// ones. The url is returned as is when there are no parameters to add.
+func mergeQuery(rawUrl string, defaults, query url.Values) (string, error) {
+	if len(defaults) == 0 && len(query) == 0 {
+		return rawUrl, nil
+	}
+
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", err
+	}
+
+	merged := make(url.Values)
+	for _, values := range []url.Values{defaults, u.Query(), query} {
+		for key, value := range values {
+			merged[key] = value
+		}
+	}
+	u.RawQuery = merged.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,135 @@
+package gohttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Pagination struct {
+	Page    int `url:"page,omitempty"`
+	PerPage int `url:"per_page,omitempty"`
+}
+
+type RepoFilter struct {
+	Language string `url:"language"`
+	Stars    *int   `url:"stars,omitempty"`
+}
+
+type RepoQuery struct {
+	Pagination
+	Query   string     `url:"q"`
+	Topics  []string   `url:"topic"`
+	Ids     []int      `url:"ids,comma"`
+	Since   time.Time  `url:"since"`
+	Until   time.Time  `url:"until,unix"`
+	Day     time.Time  `url:"day" layout:"2006-01-02"`
+	Filter  RepoFilter `url:"filter"`
+	Private bool       `url:"private,omitempty"`
+	Owner   string     `url:",omitempty"`
+	Secret  string     `url:"-"`
+	hidden  string
+}
+
+func TestEncodeQuery(t *testing.T) {
+	t.Run("Struct", func(t *testing.T) {
+		date := time.Date(2021, 11, 16, 10, 30, 0, 0, time.UTC)
+		values, err := EncodeQuery(&RepoQuery{
+			Pagination: Pagination{Page: 2},
+			Query:      "http client",
+			Topics:     []string{"go", "http"},
+			Ids:        []int{1, 2, 3},
+			Since:      date,
+			Until:      date,
+			Day:        date,
+			Filter:     RepoFilter{Language: "go"},
+			Secret:     "secret",
+			hidden:     "hidden",
+		})
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, url.Values{
+			"page":             {"2"},
+			"q":                {"http client"},
+			"topic":            {"go", "http"},
+			"ids":              {"1,2,3"},
+			"since":            {"2021-11-16T10:30:00Z"},
+			"until":            {"1637058600"},
+			"day":              {"2021-11-16"},
+			"filter[language]": {"go"},
+		}, values)
+	})
+
+	t.Run("Map", func(t *testing.T) {
+		values, err := EncodeQuery(map[string]interface{}{
+			"page": 1,
+			"tags": []string{"a", "b"},
+		})
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "page=1&tags=a&tags=b", values.Encode())
+	})
+
+	t.Run("UrlValues", func(t *testing.T) {
+		query := url.Values{"page": {"1"}}
+		values, err := EncodeQuery(query)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, query, values)
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		values, err := EncodeQuery(nil)
+
+		assert.Nil(t, err)
+		assert.Empty(t, values)
+	})
+
+	t.Run("UnsupportedType", func(t *testing.T) {
+		values, err := EncodeQuery("page=1")
+
+		assert.Nil(t, values)
+		assert.EqualValues(t, "query must be url.Values, a map or a struct, got string", err.Error())
+	})
+}
+
+func TestMergeQuery(t *testing.T) {
+	t.Run("NoParams", func(t *testing.T) {
+		result, err := mergeQuery("https://api.example.com/users?z=1&a=2", nil, nil)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "https://api.example.com/users?z=1&a=2", result)
+	})
+
+	t.Run("Precedence", func(t *testing.T) {
+		result, err := mergeQuery("https://api.example.com/users?page=2&sort=name",
+			url.Values{"api_key": {"secret"}, "page": {"1"}},
+			url.Values{"sort": {"created"}},
+		)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "https://api.example.com/users?api_key=secret&page=2&sort=created", result)
+	})
+}
+
+func TestDefaultQueryParams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RawQuery))
+	}))
+	defer server.Close()
+
+	client, err := NewBuilder().
+		SetBaseUrl(server.URL).
+		SetQueryParams(url.Values{"api_key": {"secret"}}).
+		Build()
+	assert.Nil(t, err)
+
+	response, err := client.Get("/users?page=2")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "api_key=secret&page=2", response.String())
+}
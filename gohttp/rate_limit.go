@@ -0,0 +1,145 @@
+package gohttp
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrRateLimited is returned when the rate limiters do not allow a request
+// right away and the client fails fast, see EnableRateLimitFailFast.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// routeRateLimiter is the rate limiter of the requests matching a method,
+// a host and a path pattern.
+type routeRateLimiter struct {
+	host     string
+	method   string
+	segments []string
+	limiter  *rate.Limiter
+}
+
+func newRouteRateLimiter(host, method, pattern string, limiter *rate.Limiter) *routeRateLimiter {
+	return &routeRateLimiter{
+		host:     strings.ToLower(host),
+		method:   strings.ToUpper(method),
+		segments: splitPath(pattern),
+		limiter:  limiter,
+	}
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// matches checks whether the request matches the route. Each "{param}" or
+// "*" segment of the pattern matches a single segment of the path.
+func (r *routeRateLimiter) matches(req *http.Request) bool {
+	if r.method != "" && r.method != req.Method {
+		return false
+	}
+	if r.host != "" && !matchesHost(r.host, req) {
+		return false
+	}
+
+	segments := splitPath(req.URL.Path)
+	if len(segments) != len(r.segments) {
+		return false
+	}
+	for i, segment := range r.segments {
+		isParam := segment == "*" || (strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"))
+		if isParam {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesHost checks whether the host, with or without port, is the one of
+// the request url.
+func matchesHost(host string, req *http.Request) bool {
+	return host == strings.ToLower(req.URL.Host) || host == strings.ToLower(req.URL.Hostname())
+}
+
+// getRateLimit returns the rate limiter of the request: the first route
+// limiter it matches, otherwise the limiter of its host, otherwise the
+// default one, allocated once by Build.
+func (c *httpClient) getRateLimit(req *http.Request) *rate.Limiter {
+	for _, route := range c.builder.routeRateLimiters {
+		if route.matches(req) {
+			return route.limiter
+		}
+	}
+	if len(c.builder.hostRateLimiters) > 0 {
+		if limiter, ok := c.builder.hostRateLimiters[strings.ToLower(req.URL.Host)]; ok {
+			return limiter
+		}
+		if limiter, ok := c.builder.hostRateLimiters[strings.ToLower(req.URL.Hostname())]; ok {
+			return limiter
+		}
+	}
+	return c.rateLimiter
+}
+
+// waitRateLimit blocks until the rate limiters allow the request, or fails
+// with ErrRateLimited if it would have to wait and the client fails fast.
+// When failing fast, the tokens of the local limiter are checked before the
+// server rate limit and only taken once both allow the request, so a
+// rejected request uses up neither of them.
+func (c *httpClient) waitRateLimit(req *http.Request) error {
+	limiter := c.getRateLimit(req)
+	if c.builder.rateLimitFailFast {
+		if limiter.Limit() != rate.Inf && limiter.Tokens() < 1 {
+			return ErrRateLimited
+		}
+		if c.adaptiveLimiter != nil && !c.adaptiveLimiter.allow(req.URL.Host) {
+			return ErrRateLimited
+		}
+		if !limiter.Allow() {
+			return ErrRateLimited
+		}
+		return nil
+	}
+
+	if c.adaptiveLimiter != nil {
+		if err := c.adaptiveLimiter.wait(req.Context(), req.URL.Host); err != nil {
+			return err
+		}
+	}
+	return limiter.Wait(req.Context()) // This is a blocking call. Honors the rate limit
+}
+
+// SetRateLimit changes the rate of the default rate limiter, set with
+// SetRateLimiter, while the client is in use. A burst of zero, such as the
+// one of the unlimited default limiter, is raised to 1 so requests are still
+// allowed at the new rate.
+func (c *httpClient) SetRateLimit(r rate.Limit) {
+	if r != rate.Inf && c.rateLimiter.Burst() == 0 {
+		c.rateLimiter.SetBurst(1)
+	}
+	c.rateLimiter.SetLimit(r)
+}
+
+// SetRateBurst changes the number of requests the default rate limiter
+// allows at once, while the client is in use.
+func (c *httpClient) SetRateBurst(requests int) {
+	c.rateLimiter.SetBurst(requests)
+}
+
+// RateLimitTokens returns the number of requests the default rate limiter
+// allows right now, or +Inf if it is unlimited.
+func (c *httpClient) RateLimitTokens() float64 {
+	if c.rateLimiter.Limit() == rate.Inf {
+		return math.Inf(1)
+	}
+	return c.rateLimiter.Tokens()
+}
@@ -0,0 +1,245 @@
+package gohttp
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestRouteRateLimiterMatches(t *testing.T) {
+	route := newRouteRateLimiter("API.example.com", "post", "/repos/{owner}/*/issues/", nil)
+
+	testCases := []struct {
+		name    string
+		method  string
+		url     string
+		matches bool
+	}{
+		{"Matches", http.MethodPost, "https://api.example.com/repos/john/app/issues", true},
+		{"MatchesHostWithPort", http.MethodPost, "https://api.example.com:8443/repos/john/app/issues/", true},
+		{"OtherMethod", http.MethodGet, "https://api.example.com/repos/john/app/issues", false},
+		{"OtherHost", http.MethodPost, "https://example.com/repos/john/app/issues", false},
+		{"OtherPath", http.MethodPost, "https://api.example.com/repos/john/app/pulls", false},
+		{"EmptySegment", http.MethodPost, "https://api.example.com/repos//app/issues", false},
+		{"MoreSegments", http.MethodPost, "https://api.example.com/repos/john/app/issues/1", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			assert.EqualValues(t, tc.matches, route.matches(req))
+		})
+	}
+
+	t.Run("AnyHostAndMethod", func(t *testing.T) {
+		route := newRouteRateLimiter("", "", "/users/{id}", nil)
+		assert.True(t, route.matches(httptest.NewRequest(http.MethodDelete, "http://a.example.com/users/1", nil)))
+		assert.False(t, route.matches(httptest.NewRequest(http.MethodDelete, "http://a.example.com/users", nil)))
+	})
+}
+
+func TestGetRateLimit(t *testing.T) {
+	builder := NewBuilder().
+		SetRateLimiter(1, 1).
+		SetHostRateLimiter("A.example.com", 2, 2).
+		SetHostRateLimiter("b.example.com:8080", 3, 3).
+		SetRouteRateLimiter("a.example.com", http.MethodGet, "/users/{id}", 4, 4).
+		SetRouteRateLimiter("", "", "/users/*", 5, 5).(*clientBuilder)
+	client := &httpClient{builder: builder, rateLimiter: builder.rateLimiter}
+
+	testCases := []struct {
+		name   string
+		method string
+		url    string
+		limit  rate.Limit
+	}{
+		{"Default", http.MethodGet, "http://c.example.com/", 1},
+		{"Host", http.MethodGet, "http://a.example.com:8080/", 2},
+		{"HostWithPort", http.MethodGet, "http://b.example.com:8080/", 3},
+		{"HostWithOtherPort", http.MethodGet, "http://b.example.com/", 1},
+		{"Route", http.MethodGet, "http://a.example.com/users/1", 4},
+		{"FirstMatchingRoute", http.MethodPost, "http://a.example.com/users/1", 5},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			assert.EqualValues(t, tc.limit, client.getRateLimit(req).Limit())
+		})
+	}
+
+}
+
+func TestRouteRateLimiterClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client, err := NewBuilder().
+		SetBaseUrl(server.URL).
+		SetRouteRateLimiter("", http.MethodGet, "/slow", rate.Every(time.Hour), 1).
+		Build()
+	assert.Nil(t, err)
+
+	_, err = client.Get("/slow")
+	assert.Nil(t, err)
+
+	// The other routes are not limited by the noisy one.
+	_, err = client.Get("/fast", WithTimeout(time.Second))
+	assert.Nil(t, err)
+	_, err = client.Get("/fast", WithTimeout(time.Second))
+	assert.Nil(t, err)
+
+	_, err = client.Get("/slow", WithTimeout(50*time.Millisecond))
+	assert.NotNil(t, err)
+}
+
+func TestDefaultRateLimiter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	t.Run("AllocatedOnce", func(t *testing.T) {
+		client, err := NewBuilder().Build()
+		assert.Nil(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		limiter := client.(*httpClient).getRateLimit(req)
+		assert.EqualValues(t, rate.Inf, limiter.Limit())
+		assert.True(t, limiter == client.(*httpClient).getRateLimit(req))
+		assert.True(t, math.IsInf(client.RateLimitTokens(), 1))
+	})
+
+	t.Run("Reconfigure", func(t *testing.T) {
+		client, err := NewBuilder().
+			SetBaseUrl(server.URL).
+			SetRateLimiter(rate.Every(time.Hour), 1).
+			Build()
+		assert.Nil(t, err)
+		assert.InDelta(t, 1, client.RateLimitTokens(), 0.01)
+
+		_, err = client.Get("/")
+		assert.Nil(t, err)
+		assert.InDelta(t, 0, client.RateLimitTokens(), 0.01)
+
+		client.SetRateLimit(rate.Inf)
+		_, err = client.Get("/", WithTimeout(time.Second))
+		assert.Nil(t, err)
+
+		client.SetRateLimit(rate.Every(time.Hour))
+		client.SetRateBurst(3)
+		assert.EqualValues(t, 3, client.(*httpClient).rateLimiter.Burst())
+	})
+
+	t.Run("LimitDefault", func(t *testing.T) {
+		client, err := NewBuilder().SetBaseUrl(server.URL).Build()
+		assert.Nil(t, err)
+
+		client.SetRateLimit(10)
+		response, err := client.Get("/", WithTimeout(time.Second))
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusOK, response.StatusCode)
+		assert.EqualValues(t, 1, client.(*httpClient).rateLimiter.Burst())
+	})
+
+	t.Run("FailFast", func(t *testing.T) {
+		client, err := NewBuilder().
+			SetBaseUrl(server.URL).
+			SetRateLimiter(rate.Every(time.Hour), 1).
+			EnableRateLimitFailFast(true).
+			Build()
+		assert.Nil(t, err)
+
+		_, err = client.Get("/")
+		assert.Nil(t, err)
+
+		start := time.Now()
+		_, err = client.Get("/")
+		assert.EqualValues(t, ErrRateLimited, err)
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	})
+
+	t.Run("FailFastAdaptive", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		client, err := NewBuilder().
+			SetBaseUrl(server.URL).
+			EnableAdaptiveRateLimit(true).
+			EnableRateLimitFailFast(true).
+			Build()
+		assert.Nil(t, err)
+
+		_, err = client.Get("/")
+		assert.Nil(t, err)
+		_, err = client.Get("/")
+		assert.EqualValues(t, ErrRateLimited, err)
+	})
+
+	t.Run("FailFastKeepsBudgets", func(t *testing.T) {
+		var limited int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("RateLimit", "r=50;t=60")
+			if atomic.LoadInt32(&limited) == 1 {
+				w.Header().Set("Retry-After", "60")
+				w.WriteHeader(http.StatusTooManyRequests)
+			}
+		}))
+		defer server.Close()
+
+		client, err := NewBuilder().
+			SetBaseUrl(server.URL).
+			SetRateLimiter(rate.Every(time.Hour), 2).
+			EnableAdaptiveRateLimit(true).
+			EnableRateLimitFailFast(true).
+			Build()
+		assert.Nil(t, err)
+		adaptive := client.(*httpClient).adaptiveLimiter
+		host := strings.TrimPrefix(server.URL, "http://")
+
+		atomic.StoreInt32(&limited, 1)
+		_, err = client.Get("/")
+		assert.Nil(t, err)
+		assert.InDelta(t, 1, client.RateLimitTokens(), 0.01)
+
+		// The server rate limit rejects the request, the local token is kept.
+		_, err = client.Get("/")
+		assert.EqualValues(t, ErrRateLimited, err)
+		assert.InDelta(t, 1, client.RateLimitTokens(), 0.01)
+
+		// The local limiter rejects the request, the server budget is kept.
+		adaptive.mutex.Lock()
+		adaptive.hosts[host].blockedUntil = time.Time{}
+		adaptive.mutex.Unlock()
+		atomic.StoreInt32(&limited, 0)
+		_, err = client.Get("/")
+		assert.Nil(t, err)
+		_, err = client.Get("/")
+		assert.EqualValues(t, ErrRateLimited, err)
+		assert.EqualValues(t, 50, adaptive.hosts[host].remaining)
+	})
+
+	t.Run("FailFastBaseUrls", func(t *testing.T) {
+		client, err := NewBuilder().
+			SetBaseUrls([]string{server.URL, server.URL + "/v2"}).
+			SetEndpointEjection(1, time.Minute).
+			SetRateLimiter(rate.Every(time.Hour), 1).
+			EnableRateLimitFailFast(true).
+			Build()
+		assert.Nil(t, err)
+
+		_, err = client.Get("/")
+		assert.Nil(t, err)
+		_, err = client.Get("/")
+		assert.EqualValues(t, ErrRateLimited, err)
+		for _, status := range client.Endpoints() {
+			assert.False(t, status.Ejected)
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package gohttp
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/getmiranda/go-httpclient/core"
+)
+
+// RequestBuilder builds a single request step by step and sends it through
+// the client, applying its default headers, user agent, base url, default
+// query parameters and body encoding.
+//
+// The first invalid setting is returned as an error when the request is sent.
+type RequestBuilder struct {
+	client  *httpClient
+	request *request
+	err     error
+}
+
+// R returns a new request builder for the client.
+func (c *httpClient) R() *RequestBuilder {
+	return &RequestBuilder{
+		client:  c,
+		request: &request{method: http.MethodGet},
+	}
+}
+
+func (b *RequestBuilder) apply(opt RequestOption) *RequestBuilder {
+	if b.err == nil {
+		b.err = opt(b.request)
+	}
+	return b
+}
+
+// SetMethod sets the HTTP method of the request. Defaults to GET.
+func (b *RequestBuilder) SetMethod(method string) *RequestBuilder {
+	b.request.method = method
+	return b
+}
+
+// SetUrl sets the url of the request, relative to the base url of the
+// client unless it is absolute. It could be a template, expanded with the
+// parameters set with SetPathParam or SetPathParams.
+func (b *RequestBuilder) SetUrl(url string) *RequestBuilder {
+	b.request.url = url
+	return b
+}
+
+// SetPathParam sets the value of a parameter of the url template.
+func (b *RequestBuilder) SetPathParam(name, value string) *RequestBuilder {
+	return b.apply(WithPathParams(map[string]string{name: value}))
+}
+
+// SetPathParams sets the values of the parameters of the url template.
+func (b *RequestBuilder) SetPathParams(params map[string]string) *RequestBuilder {
+	return b.apply(WithPathParams(params))
+}
+
+// SetHeader sets the given header, replacing the common header with the
+// same name.
+func (b *RequestBuilder) SetHeader(key, value string) *RequestBuilder {
+	return b.apply(WithHeader(key, value))
+}
+
+// SetHeaders sets the given headers, replacing the common headers with the
+// same name.
+func (b *RequestBuilder) SetHeaders(headers http.Header) *RequestBuilder {
+	return b.apply(WithHeaders(headers))
+}
+
+// SetQuery adds the given query parameters to the url, as described in
+// WithQuery.
+func (b *RequestBuilder) SetQuery(query interface{}) *RequestBuilder {
+	return b.apply(WithQuery(query))
+}
+
+// SetQueryParam sets the value of a query parameter of the url.
+func (b *RequestBuilder) SetQueryParam(key, value string) *RequestBuilder {
+	return b.apply(WithQuery(map[string]string{key: value}))
+}
+
+// SetBody sets the body of the request, encoded according to its
+// Content-Type header.
+//
+// Body could be any of the form: string, []byte, struct & map.
+func (b *RequestBuilder) SetBody(body interface{}) *RequestBuilder {
+	b.request.body = body
+	return b
+}
+
+// SetBasicAuth sets the Authorization header of the request to use
+// HTTP Basic Authentication with the given username and password.
+func (b *RequestBuilder) SetBasicAuth(username, password string) *RequestBuilder {
+	return b.apply(WithBasicAuth(username, password))
+}
+
+// SetBearerToken sets the Authorization header of the request to use
+// the given bearer token.
+func (b *RequestBuilder) SetBearerToken(token string) *RequestBuilder {
+	return b.apply(WithBearerToken(token))
+}
+
+// SetContext sets the context of the request.
+func (b *RequestBuilder) SetContext(ctx context.Context) *RequestBuilder {
+	return b.apply(WithContext(ctx))
+}
+
+// SetTimeout sets the maximum amount of time for the whole request,
+// including any retries and reading the response body.
+func (b *RequestBuilder) SetTimeout(timeout time.Duration) *RequestBuilder {
+	return b.apply(WithTimeout(timeout))
+}
+
+// SetRetry sends the request again when it fails, as described in
+// WithRetry.
+func (b *RequestBuilder) SetRetry(maxRetries int, backoff time.Duration) *RequestBuilder {
+	return b.apply(WithRetry(maxRetries, backoff))
+}
+
+// SetResult sets the target the response body is decoded into when the
+// request succeeds with a 2xx status code.
+func (b *RequestBuilder) SetResult(result interface{}) *RequestBuilder {
+	return b.apply(WithResult(result))
+}
+
+// SetError sets the target the response body is decoded into when the
+// request fails with a 4xx or 5xx status code.
+func (b *RequestBuilder) SetError(errorResult interface{}) *RequestBuilder {
+	return b.apply(WithError(errorResult))
+}
+
+// SetOptions applies the given request options.
+func (b *RequestBuilder) SetOptions(opts ...RequestOption) *RequestBuilder {
+	for _, opt := range opts {
+		b.apply(opt)
+	}
+	return b
+}
+
+// Send sends the request.
+func (b *RequestBuilder) Send() (*core.Response, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.client.do(b.request)
+}
+
+// Get sends the request as a GET to the given url.
+func (b *RequestBuilder) Get(url string) (*core.Response, error) {
+	return b.SetMethod(http.MethodGet).SetUrl(url).Send()
+}
+
+// Post sends the request as a POST to the given url.
+func (b *RequestBuilder) Post(url string) (*core.Response, error) {
+	return b.SetMethod(http.MethodPost).SetUrl(url).Send()
+}
+
+// Put sends the request as a PUT to the given url.
+func (b *RequestBuilder) Put(url string) (*core.Response, error) {
+	return b.SetMethod(http.MethodPut).SetUrl(url).Send()
+}
+
+// Patch sends the request as a PATCH to the given url.
+func (b *RequestBuilder) Patch(url string) (*core.Response, error) {
+	return b.SetMethod(http.MethodPatch).SetUrl(url).Send()
+}
+
+// Delete sends the request as a DELETE to the given url.
+func (b *RequestBuilder) Delete(url string) (*core.Response, error) {
+	return b.SetMethod(http.MethodDelete).SetUrl(url).Send()
+}
+
+// Head sends the request as a HEAD to the given url.
+func (b *RequestBuilder) Head(url string) (*core.Response, error) {
+	return b.SetMethod(http.MethodHead).SetUrl(url).Send()
+}
+
+// Options sends the request as an OPTIONS to the given url.
+func (b *RequestBuilder) Options(url string) (*core.Response, error) {
+	return b.SetMethod(http.MethodOptions).SetUrl(url).Send()
+}
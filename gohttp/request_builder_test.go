@@ -0,0 +1,132 @@
+package gohttp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/getmiranda/go-httpclient/gomime"
+	"github.com/stretchr/testify/assert"
+)
+
+type echoResponse struct {
+	Method    string `json:"method"`
+	Uri       string `json:"uri"`
+	UserAgent string `json:"user_agent"`
+	Header    string `json:"header"`
+	Auth      string `json:"auth"`
+	Body      string `json:"body"`
+}
+
+func newEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"not found"}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.NewEncoder(w).Encode(echoResponse{
+			Method:    r.Method,
+			Uri:       r.URL.RequestURI(),
+			UserAgent: r.UserAgent(),
+			Header:    r.Header.Get("X-Common"),
+			Auth:      r.Header.Get(gomime.HeaderAuthorization),
+			Body:      string(body),
+		})
+	}))
+}
+
+func newEchoClient(t *testing.T, baseUrl string) Client {
+	headers := make(http.Header)
+	headers.Set("X-Common", "common")
+	headers.Set(gomime.HeaderContentType, gomime.ContentTypeJson)
+	client, err := NewBuilder().
+		SetBaseUrl(baseUrl).
+		SetHeaders(headers).
+		SetUserAgent("go-httpclient").
+		SetQueryParams(url.Values{"api_key": {"secret"}}).
+		Build()
+	assert.Nil(t, err)
+	return client
+}
+
+func TestRequestBuilder(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+	client := newEchoClient(t, server.URL)
+
+	t.Run("FullPipeline", func(t *testing.T) {
+		var result echoResponse
+		response, err := client.R().
+			SetPathParam("id", "1").
+			SetQueryParam("page", "2").
+			SetBearerToken("token").
+			SetBody(map[string]string{"name": "john"}).
+			SetResult(&result).
+			Post("/users/{id}")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusOK, response.StatusCode)
+		assert.EqualValues(t, echoResponse{
+			Method:    http.MethodPost,
+			Uri:       "/users/1?api_key=secret&page=2",
+			UserAgent: "go-httpclient",
+			Header:    "common",
+			Auth:      "Bearer token",
+			Body:      `{"name":"john"}`,
+		}, result)
+	})
+
+	t.Run("ErrorTarget", func(t *testing.T) {
+		var result echoResponse
+		var apiError struct {
+			Message string `json:"message"`
+		}
+		response, err := client.R().SetResult(&result).SetError(&apiError).Get("/missing")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusNotFound, response.StatusCode)
+		assert.EqualValues(t, "not found", apiError.Message)
+		assert.EqualValues(t, echoResponse{}, result)
+	})
+
+	t.Run("InvalidSetting", func(t *testing.T) {
+		response, err := client.R().SetQuery(42).SetHeader("X-Request-Id", "ABC-123").Get("/users")
+
+		assert.Nil(t, response)
+		assert.EqualValues(t, "query must be url.Values, a map or a struct, got int", err.Error())
+	})
+}
+
+func TestDoWithClientDefaults(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+	client := newEchoClient(t, server.URL)
+
+	t.Run("WithoutDefaults", func(t *testing.T) {
+		var result echoResponse
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/users", nil)
+		_, err := client.Do(req, WithResult(&result))
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "/users", result.Uri)
+		assert.EqualValues(t, "", result.Header)
+	})
+
+	t.Run("WithDefaults", func(t *testing.T) {
+		var result echoResponse
+		req, _ := http.NewRequest(http.MethodGet, "/users?page=2", nil)
+		req.Header.Set("X-Common", "custom")
+		_, err := client.Do(req, WithClientDefaults(), WithResult(&result))
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "/users?api_key=secret&page=2", result.Uri)
+		assert.EqualValues(t, "custom", result.Header)
+		assert.EqualValues(t, "go-httpclient", result.UserAgent)
+		assert.EqualValues(t, "/users?page=2", req.URL.String())
+	})
+}
@@ -0,0 +1,88 @@
+package gohttp
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/getmiranda/go-httpclient/core"
+	"github.com/getmiranda/go-httpclient/gomime"
+)
+
+// WithResult sets the target the response body is decoded into when the
+// request succeeds with a 2xx status code.
+//
+// The body is decoded according to the Content-Type of the response, as
+// described in DecodeResponse.
+func WithResult(result interface{}) RequestOption {
+	return func(r *request) error {
+		r.result = result
+		return nil
+	}
+}
+
+// WithError sets the target the response body is decoded into when the
+// request fails with a 4xx or 5xx status code.
+//
+// If the target implements error, it is also returned as the error of the
+// request, together with the response.
+func WithError(errorResult interface{}) RequestOption {
+	return func(r *request) error {
+		r.errorResult = errorResult
+		return nil
+	}
+}
+
+// DecodeResponse decodes the response body into the given target according
+// to the Content-Type of the response: XML for application/xml, text/xml and
+// any "+xml" type, JSON otherwise, as many APIs send JSON without the right
+// Content-Type.
+//
+// Targets of type *string or *[]byte get the raw body whatever its type.
+func DecodeResponse(response *core.Response, target interface{}) error {
+	switch t := target.(type) {
+	case *string:
+		*t = response.String()
+		return nil
+	case *[]byte:
+		*t = append([]byte(nil), response.Bytes()...)
+		return nil
+	}
+
+	if isXmlContentType(response.Header.Get(gomime.HeaderContentType)) {
+		return response.UnmarshalXml(target)
+	}
+	return response.UnmarshalJson(target)
+}
+
+func isXmlContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == gomime.ContentTypeXml || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// decodeResponse decodes the response body into the result or the error
+// target of the request, depending on its status class. The error target
+// is returned as an error when it implements error.
+func decodeResponse(request *request, response *core.Response) error {
+	switch {
+	case response.StatusCode >= 200 && response.StatusCode <= 299:
+		return decodeTarget(response, request.result)
+	case response.StatusCode >= 400 && response.StatusCode <= 599:
+		if err := decodeTarget(response, request.errorResult); err != nil {
+			return err
+		}
+		if err, ok := request.errorResult.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeTarget(response *core.Response, target interface{}) error {
+	if target == nil || len(response.Bytes()) == 0 {
+		return nil
+	}
+	return DecodeResponse(response, target)
+}
@@ -0,0 +1,139 @@
+package gohttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getmiranda/go-httpclient/core"
+	"github.com/getmiranda/go-httpclient/gomime"
+	"github.com/stretchr/testify/assert"
+)
+
+type apiError struct {
+	Code    string `json:"code" xml:"code"`
+	Message string `json:"message" xml:"message"`
+}
+
+func (e *apiError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+type apiUser struct {
+	Id   int    `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+func newTestResponse(contentType, body string) *core.Response {
+	response := &core.Response{
+		BodyBytes: []byte(body),
+		Response:  &http.Response{Header: make(http.Header)},
+	}
+	if contentType != "" {
+		response.Header.Set(gomime.HeaderContentType, contentType)
+	}
+	return response
+}
+
+func TestDecodeResponse(t *testing.T) {
+	t.Run("Json", func(t *testing.T) {
+		var user apiUser
+		err := DecodeResponse(newTestResponse("application/problem+json; charset=utf-8", `{"id":1,"name":"john"}`), &user)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, apiUser{Id: 1, Name: "john"}, user)
+	})
+
+	t.Run("Xml", func(t *testing.T) {
+		var user apiUser
+		err := DecodeResponse(newTestResponse("text/xml", `<user><id>1</id><name>john</name></user>`), &user)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, apiUser{Id: 1, Name: "john"}, user)
+	})
+
+	t.Run("NoContentType", func(t *testing.T) {
+		var user apiUser
+		err := DecodeResponse(newTestResponse("", `{"id":1}`), &user)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 1, user.Id)
+	})
+
+	t.Run("RawBody", func(t *testing.T) {
+		var body string
+		err := DecodeResponse(newTestResponse("text/html", "<h1>Bad Gateway</h1>"), &body)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "<h1>Bad Gateway</h1>", body)
+	})
+
+	t.Run("JsonAsDefault", func(t *testing.T) {
+		var user apiUser
+		err := DecodeResponse(newTestResponse("text/plain; charset=utf-8", `{"id":1}`), &user)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 1, user.Id)
+	})
+
+	t.Run("InvalidBody", func(t *testing.T) {
+		var user apiUser
+		err := DecodeResponse(newTestResponse("text/html", "<h1>Bad Gateway</h1>"), &user)
+
+		assert.EqualValues(t, "invalid character '<' looking for beginning of value", err.Error())
+	})
+}
+
+func TestResultTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/1":
+			w.Header().Set(gomime.HeaderContentType, gomime.ContentTypeJson)
+			w.Write([]byte(`{"id":1,"name":"john"}`))
+		case "/users/2":
+			w.Header().Set(gomime.HeaderContentType, gomime.ContentTypeXml)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<error><code>not_found</code><message>user not found</message></error>`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewBuilder().SetBaseUrl(server.URL).Build()
+	assert.Nil(t, err)
+
+	t.Run("Success", func(t *testing.T) {
+		var user apiUser
+		var errorResult apiError
+		response, err := client.Get("/users/1", WithResult(&user), WithError(&errorResult))
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusOK, response.StatusCode)
+		assert.EqualValues(t, apiUser{Id: 1, Name: "john"}, user)
+		assert.EqualValues(t, apiError{}, errorResult)
+	})
+
+	t.Run("ErrorTargetAsError", func(t *testing.T) {
+		var user apiUser
+		var errorResult apiError
+		response, err := client.Get("/users/2", WithResult(&user), WithError(&errorResult))
+
+		assert.NotNil(t, response)
+		assert.EqualValues(t, http.StatusNotFound, response.StatusCode)
+		assert.EqualValues(t, "not_found: user not found", err.Error())
+
+		var target *apiError
+		assert.True(t, errors.As(err, &target))
+		assert.EqualValues(t, apiUser{}, user)
+	})
+
+	t.Run("EmptyBody", func(t *testing.T) {
+		var user apiUser
+		response, err := client.Delete("/users/3", WithResult(&user))
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusNoContent, response.StatusCode)
+	})
+}
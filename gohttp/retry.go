@@ -0,0 +1,68 @@
+package gohttp
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/getmiranda/go-httpclient/core"
+)
+
+const maxRetryBackoff = time.Second * 30
+
+type retryPolicy struct {
+	maxRetries int
+	backoff    time.Duration
+}
+
+// shouldRetry checks whether a request that got the given response or error
+// should be sent again.
+func (p *retryPolicy) shouldRetry(response *core.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return response.StatusCode == http.StatusTooManyRequests || isEndpointFailure(response.StatusCode)
+}
+
+// getBackoff returns the wait before the given retry, starting at zero.
+func (p *retryPolicy) getBackoff(retry int) time.Duration {
+	backoff := p.backoff
+	for i := 0; i < retry && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return backoff
+}
+
+// doWithRetry sends the request until it succeeds, the retries are
+// exhausted or the context of the request is done.
+func (c *httpClient) doWithRetry(request *request) (*core.Response, error) {
+	ctx := request.getContext()
+	for retry := 0; ; retry++ {
+		response, err := c.doOnce(request)
+		if retry >= request.retry.maxRetries || !request.retry.shouldRetry(response, err) || ctx.Err() != nil {
+			return response, err
+		}
+		if sleep(ctx, request.retry.getBackoff(retry)) != nil {
+			return response, err
+		}
+	}
+}
+
+// sleep waits for the given duration, returning early with an error if the
+// context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
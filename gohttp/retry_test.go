@@ -0,0 +1,77 @@
+package gohttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetry(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	client, err := NewBuilder().SetBaseUrl(server.URL).Build()
+	assert.Nil(t, err)
+
+	t.Run("Succeeds", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		response, err := client.Post("/users", "john", WithRetry(3, time.Millisecond))
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusOK, response.StatusCode)
+		assert.EqualValues(t, `"john"`, response.String())
+		assert.EqualValues(t, 3, atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("Exhausted", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		response, err := client.Get("/users", WithRetry(1, time.Millisecond))
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusServiceUnavailable, response.StatusCode)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("RewindsDoBody", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		req, _ := http.NewRequest(http.MethodPut, server.URL+"/users/1", strings.NewReader("john"))
+		response, err := client.Do(req, WithRetry(2, time.Millisecond))
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "john", response.String())
+	})
+
+	t.Run("BuffersDoBody", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		body := io.NopCloser(strings.NewReader("john"))
+		req, _ := http.NewRequest(http.MethodPut, server.URL+"/users/1", body)
+		response, err := client.Do(req, WithRetry(2, time.Millisecond))
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&attempts))
+		assert.EqualValues(t, "john", response.String())
+		assert.Nil(t, req.GetBody)
+	})
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := &retryPolicy{backoff: 100 * time.Millisecond}
+
+	assert.EqualValues(t, 100*time.Millisecond, policy.getBackoff(0))
+	assert.EqualValues(t, 400*time.Millisecond, policy.getBackoff(2))
+	assert.EqualValues(t, maxRetryBackoff, policy.getBackoff(20))
+}
@@ -0,0 +1,126 @@
+package gohttp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// getTLSConfig returns the TLS configuration to be used by the transport,
+// or nil if no TLS setting was configured in the builder.
+func (c *httpClient) getTLSConfig() (*tls.Config, error) {
+	if !c.hasTLSSettings() {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   c.builder.minTLSVersion,
+		CipherSuites: c.builder.cipherSuites,
+		ServerName:   c.builder.serverName,
+	}
+
+	if c.builder.certReload > 0 {
+		if len(c.builder.clientCertPEM) > 0 || len(c.builder.clientKeyPEM) > 0 {
+			if c.builder.clientCertFile != "" || c.builder.clientKeyFile != "" {
+				return nil, errors.New("client certificate must be set either from files or from PEM bytes, not both")
+			}
+		}
+		if c.builder.clientCertFile == "" || c.builder.clientKeyFile == "" {
+			return nil, errors.New("client certificate reload requires the certificate and key files")
+		}
+		reloader, err := newCertificateReloader(c.builder.clientCertFile, c.builder.clientKeyFile, c.builder.certReload)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = reloader.GetClientCertificate
+	} else {
+		certificate, err := c.getClientCertificate()
+		if err != nil {
+			return nil, err
+		}
+		if certificate != nil {
+			tlsConfig.Certificates = []tls.Certificate{*certificate}
+		}
+	}
+
+	rootCAs, err := c.getRootCAs()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.RootCAs = rootCAs
+
+	if len(c.builder.publicKeyPins) > 0 || len(c.builder.backupPublicKeyPins) > 0 {
+		pinner, err := newPublicKeyPinner(c.builder.publicKeyPins, c.builder.backupPublicKeyPins, c.builder.pinningReportOnly)
+		if err != nil {
+			return nil, err
+		}
+		if c.builder.serverName != "" {
+			if err := pinner.checkServerName(c.builder.serverName); err != nil {
+				return nil, err
+			}
+		}
+		tlsConfig.VerifyConnection = pinner.VerifyConnection
+	}
+
+	return tlsConfig, nil
+}
+
+func (c *httpClient) hasTLSSettings() bool {
+	return c.builder.clientCertFile != "" ||
+		c.builder.clientKeyFile != "" ||
+		c.builder.certReload > 0 ||
+		len(c.builder.clientCertPEM) > 0 ||
+		len(c.builder.clientKeyPEM) > 0 ||
+		c.builder.rootCAs != nil ||
+		len(c.builder.rootCAFiles) > 0 ||
+		c.builder.minTLSVersion != 0 ||
+		len(c.builder.cipherSuites) > 0 ||
+		c.builder.serverName != "" ||
+		len(c.builder.publicKeyPins) > 0 ||
+		len(c.builder.backupPublicKeyPins) > 0
+}
+
+func (c *httpClient) getClientCertificate() (*tls.Certificate, error) {
+	if len(c.builder.clientCertPEM) > 0 || len(c.builder.clientKeyPEM) > 0 {
+		if c.builder.clientCertFile != "" || c.builder.clientKeyFile != "" {
+			return nil, errors.New("client certificate must be set either from files or from PEM bytes, not both")
+		}
+		certificate, err := tls.X509KeyPair(c.builder.clientCertPEM, c.builder.clientKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		return &certificate, nil
+	}
+
+	if c.builder.clientCertFile != "" || c.builder.clientKeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(c.builder.clientCertFile, c.builder.clientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		return &certificate, nil
+	}
+	return nil, nil
+}
+
+func (c *httpClient) getRootCAs() (*x509.CertPool, error) {
+	if len(c.builder.rootCAFiles) == 0 {
+		return c.builder.rootCAs, nil
+	}
+
+	pool := x509.NewCertPool()
+	if c.builder.rootCAs != nil {
+		pool = c.builder.rootCAs.Clone()
+	}
+	for _, file := range c.builder.rootCAFiles {
+		pemCerts, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error loading root CA file: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pemCerts) {
+			return nil, fmt.Errorf("no valid certificates found in root CA file %s", file)
+		}
+	}
+	return pool, nil
+}
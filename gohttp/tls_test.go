@@ -0,0 +1,234 @@
+package gohttp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func (tc *testCertificate) tlsCertificate(t *testing.T) tls.Certificate {
+	certificate, err := tls.X509KeyPair(tc.certPEM, tc.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate
+}
+
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{commonName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func newTestCertPool(certs ...*testCertificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert.cert)
+	}
+	return pool
+}
+
+func writeTestFile(t *testing.T, dir, name string, content []byte) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newMutualTLSServer starts a server that requires a client certificate signed by ca.
+// The responded body is the common name of the client certificate.
+func newMutualTLSServer(t *testing.T, ca *testCertificate) *httptest.Server {
+	serverCert := newTestCertificate(t, "localhost", ca)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert.tlsCertificate(t)},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    newTestCertPool(ca),
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCertificate(t, "test-ca", nil)
+	clientCert := newTestCertificate(t, "test-client", ca)
+	server := newMutualTLSServer(t, ca)
+
+	t.Run("CertificateFromFiles", func(t *testing.T) {
+		dir := t.TempDir()
+		client, err := NewBuilder().
+			SetClientCertificate(
+				writeTestFile(t, dir, "client.crt", clientCert.certPEM),
+				writeTestFile(t, dir, "client.key", clientCert.keyPEM),
+			).
+			SetRootCAFiles(writeTestFile(t, dir, "ca.crt", ca.certPEM)).
+			Build()
+		assert.Nil(t, err)
+
+		response, err := client.Get(server.URL)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusOK, response.StatusCode)
+		assert.EqualValues(t, "test-client", response.String())
+	})
+
+	t.Run("CertificateFromPEM", func(t *testing.T) {
+		client, err := NewBuilder().
+			SetClientCertificatePEM(clientCert.certPEM, clientCert.keyPEM).
+			SetRootCAs(newTestCertPool(ca)).
+			SetMinTLSVersion(tls.VersionTLS12).
+			SetServerName("localhost").
+			Build()
+		assert.Nil(t, err)
+
+		response, err := client.Get(server.URL)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "test-client", response.String())
+	})
+
+	t.Run("UnknownAuthority", func(t *testing.T) {
+		client, err := NewBuilder().
+			SetClientCertificatePEM(clientCert.certPEM, clientCert.keyPEM).
+			Build()
+		assert.Nil(t, err)
+
+		response, err := client.Get(server.URL)
+
+		assert.Nil(t, response)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGetTLSConfig(t *testing.T) {
+	ca := newTestCertificate(t, "test-ca", nil)
+	clientCert := newTestCertificate(t, "test-client", ca)
+
+	t.Run("NoTLSSettings", func(t *testing.T) {
+		client := &httpClient{builder: &clientBuilder{}}
+
+		tlsConfig, err := client.getTLSConfig()
+
+		assert.Nil(t, err)
+		assert.Nil(t, tlsConfig)
+	})
+
+	t.Run("CustomSettings", func(t *testing.T) {
+		client := &httpClient{builder: &clientBuilder{
+			minTLSVersion: tls.VersionTLS12,
+			cipherSuites:  []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+			serverName:    "api.example.com",
+		}}
+
+		tlsConfig, err := client.getTLSConfig()
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, tls.VersionTLS12, tlsConfig.MinVersion)
+		assert.EqualValues(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, tlsConfig.CipherSuites)
+		assert.EqualValues(t, "api.example.com", tlsConfig.ServerName)
+		assert.Nil(t, tlsConfig.RootCAs)
+		assert.Empty(t, tlsConfig.Certificates)
+	})
+
+	t.Run("CertificateFilesAndPEM", func(t *testing.T) {
+		client := &httpClient{builder: &clientBuilder{
+			clientCertFile: "client.crt",
+			clientKeyFile:  "client.key",
+			clientCertPEM:  clientCert.certPEM,
+			clientKeyPEM:   clientCert.keyPEM,
+		}}
+
+		tlsConfig, err := client.getTLSConfig()
+
+		assert.Nil(t, tlsConfig)
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "client certificate must be set either from files or from PEM bytes, not both", err.Error())
+	})
+
+	t.Run("MissingCertificateFile", func(t *testing.T) {
+		_, err := NewBuilder().
+			SetClientCertificate("does-not-exist.crt", "does-not-exist.key").
+			Build()
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "error loading client certificate")
+	})
+
+	t.Run("InvalidRootCAFile", func(t *testing.T) {
+		file := writeTestFile(t, t.TempDir(), "ca.crt", []byte("not a certificate"))
+
+		_, err := NewBuilder().SetRootCAFiles(file).Build()
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "no valid certificates found in root CA file "+file, err.Error())
+	})
+}
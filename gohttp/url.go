@@ -0,0 +1,94 @@
+package gohttp
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ExpandPath replaces the parameters of the given path template, e.g.
+// "/users/{id}/repos/{repo}", with their escaped values.
+//
+// An error is returned if a parameter of the template has no value.
+func ExpandPath(template string, params map[string]string) (string, error) {
+	var path strings.Builder
+	rest := template
+	for {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("invalid path template: unclosed parameter in %q", template)
+		}
+		end += start
+
+		name := rest[start+1 : end]
+		value, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("missing value for path parameter %q", name)
+		}
+		path.WriteString(rest[:start])
+		path.WriteString(url.PathEscape(value))
+		rest = rest[end+1:]
+	}
+	path.WriteString(rest)
+	return path.String(), nil
+}
+
+// parseBaseUrl validates the given base url, which must be an absolute
+// http or https url.
+func parseBaseUrl(baseUrl string) (*url.URL, error) {
+	u, err := url.Parse(baseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base url %q: scheme must be http or https", baseUrl)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid base url %q: missing host", baseUrl)
+	}
+	return u, nil
+}
+
+// joinUrl appends the given request url to the base url.
+//
+// Unlike the reference resolution of url.URL.ResolveReference, the path of
+// the request url is always appended to the path of the base url, with a
+// single slash between them, and the query of the base url is kept before
+// the query of the request url. Absolute request urls are used as they are.
+func joinUrl(baseUrl, requestUrl string) (string, error) {
+	ref, err := url.Parse(requestUrl)
+	if err != nil {
+		return "", err
+	}
+	if baseUrl == "" || ref.IsAbs() {
+		return ref.String(), nil
+	}
+
+	base, err := parseBaseUrl(baseUrl)
+	if err != nil {
+		return "", err
+	}
+
+	joined := *base
+	if refPath := ref.EscapedPath(); refPath != "" {
+		rawPath := strings.TrimSuffix(base.EscapedPath(), "/") + "/" + strings.TrimPrefix(refPath, "/")
+		path, err := url.PathUnescape(rawPath)
+		if err != nil {
+			return "", err
+		}
+		joined.Path, joined.RawPath = path, rawPath
+	}
+	switch {
+	case base.RawQuery == "":
+		joined.RawQuery = ref.RawQuery
+	case ref.RawQuery != "":
+		joined.RawQuery = base.RawQuery + "&" + ref.RawQuery
+	}
+	joined.Fragment, joined.RawFragment = ref.Fragment, ref.RawFragment
+
+	return joined.String(), nil
+}
@@ -0,0 +1,89 @@
+package gohttp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandPath(t *testing.T) {
+	t.Run("EscapeParams", func(t *testing.T) {
+		path, err := ExpandPath("/users/{id}/repos/{repo}", map[string]string{
+			"id":   "john doe",
+			"repo": "go/httpclient",
+		})
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "/users/john%20doe/repos/go%2Fhttpclient", path)
+	})
+
+	t.Run("NoParams", func(t *testing.T) {
+		path, err := ExpandPath("/users", nil)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "/users", path)
+	})
+
+	t.Run("MissingParam", func(t *testing.T) {
+		path, err := ExpandPath("/users/{id}", map[string]string{"name": "john"})
+
+		assert.EqualValues(t, "", path)
+		assert.EqualValues(t, `missing value for path parameter "id"`, err.Error())
+	})
+
+	t.Run("UnclosedParam", func(t *testing.T) {
+		_, err := ExpandPath("/users/{id", map[string]string{"id": "1"})
+
+		assert.EqualValues(t, `invalid path template: unclosed parameter in "/users/{id"`, err.Error())
+	})
+}
+
+func TestJoinUrl(t *testing.T) {
+	testCases := []struct {
+		name       string
+		baseUrl    string
+		requestUrl string
+		expected   string
+	}{
+		{"NoBaseUrl", "", "https://api.github.com", "https://api.github.com"},
+		{"AbsoluteRequestUrl", "https://api.example.com", "https://api.github.com/users", "https://api.github.com/users"},
+		{"SingleSlash", "https://api.example.com/", "/users", "https://api.example.com/users"},
+		{"MissingSlash", "https://api.example.com", "users", "https://api.example.com/users"},
+		{"BasePath", "https://api.example.com/v1", "/users/", "https://api.example.com/v1/users/"},
+		{"EmptyRequestUrl", "https://api.example.com/v1", "", "https://api.example.com/v1"},
+		{"EscapedPath", "https://api.example.com", "/repos/go%2Fhttpclient", "https://api.example.com/repos/go%2Fhttpclient"},
+		{"BaseQuery", "https://api.example.com/v1?api_key=secret", "/users?page=2", "https://api.example.com/v1/users?api_key=secret&page=2"},
+		{"RequestQuery", "https://api.example.com", "/users?page=2", "https://api.example.com/users?page=2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := joinUrl(tc.baseUrl, tc.requestUrl)
+
+			assert.Nil(t, err)
+			assert.EqualValues(t, tc.expected, result)
+		})
+	}
+}
+
+func TestValidateBaseUrl(t *testing.T) {
+	t.Run("MissingScheme", func(t *testing.T) {
+		_, err := NewBuilder().SetBaseUrl("api.example.com").Build()
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, `invalid base url "api.example.com": scheme must be http or https`, err.Error())
+	})
+
+	t.Run("MissingHost", func(t *testing.T) {
+		_, err := NewBuilder().SetBaseUrls([]string{"https://api.example.com", "https:///v1"}).Build()
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, `invalid base url "https:///v1": missing host`, err.Error())
+	})
+
+	t.Run("ValidBaseUrl", func(t *testing.T) {
+		_, err := NewBuilder().SetBaseUrl("https://api.example.com/v1?api_key=secret").Build()
+
+		assert.Nil(t, err)
+	})
+}